@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/anfly0/cuddly-octo-bassoon/internal/program"
+	"github.com/anfly0/cuddly-octo-bassoon/internal/robot"
+	"github.com/anfly0/cuddly-octo-bassoon/internal/storage"
+)
+
+// Errors that are detected by the handlers themselves.
+var (
+	errInvalidRequest = errors.New("invalid request")
+	errRobotNotFound  = errors.New("robot not found")
+	errInvalidCompass = errors.New("the compass must have 4 or 8 points")
+	errInternal       = errors.New("internal server error")
+)
+
+// Machine readable codes for errors that can be matched with errors.Is.
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{errInvalidRequest, "invalid_request"},
+	{errRobotNotFound, "robot_not_found"},
+	{errInvalidCompass, "invalid_compass"},
+	{errInternal, "internal_error"},
+	{storage.ErrRoomNotFound, "room_not_found"},
+	{robot.ErrOutsideRoom, "outside_room"},
+	{robot.ErrBlockedCell, "blocked_cell"},
+	{robot.ErrOccupied, "occupied"},
+	{robot.ErrInvalidDirection, "invalid_direction"},
+	{robot.ErrEmptyRoom, "empty_room"},
+	{errRoomTooLarge, "room_too_large"},
+	{robot.ErrRoomTooLarge, "room_too_large"},
+	{robot.ErrMapMismatch, "map_mismatch"},
+	{robot.ErrInvalidMap, "invalid_map"},
+	{robot.ErrRoomClosed, "room_closed"},
+	{robot.ErrRoomNotEmpty, "room_not_empty"},
+	{robot.ErrInvalidPolicy, "invalid_collision_policy"},
+	{robot.ErrNothingToUndo, "nothing_to_undo"},
+	{robot.ErrNothingToRedo, "nothing_to_redo"},
+	{robot.ErrUnreachable, "unreachable"},
+	{robot.ErrNoBattery, "no_battery"},
+	{robot.ErrBusy, "busy"},
+	{robot.ErrTooManySteps, "too_many_steps"},
+	{errCellOutsideRoom, "outside_room"},
+	{errCellBlocked, "blocked_cell"},
+	{errInvalidBattery, "invalid_battery"},
+	{errInvalidTiming, "invalid_timing"},
+	{errInvalidLimit, "invalid_limit"},
+	{program.ErrTooDeep, "too_deep"},
+}
+
+/*
+rspError is the JSON representation of an error. Code is a stable machine readable identifier and Message a human readable description.
+Index and Rune are set for invalid commands and syntax errors, Step for collisions, running out of energy and canceled executions and Fields for requests that failed validation.
+*/
+type rspError struct {
+	Code    string          `json:"code"`
+	Message string          `json:"message"`
+	Index   *int            `json:"index,omitempty"`
+	Rune    string          `json:"rune,omitempty"`
+	Step    *int            `json:"step,omitempty"`
+	Fields  []rspFieldError `json:"fields,omitempty"`
+}
+
+type rspFieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type rspErrorBody struct {
+	Error *rspError `json:"error"`
+}
+
+func RspErrorFromError(err error) *rspError {
+	rsp := &rspError{Code: "error", Message: err.Error()}
+
+	var invalid robot.ErrInvalidCommand
+	var syntax robot.ErrSyntax
+	var collision robot.ErrCollision
+	var energy robot.ErrOutOfEnergy
+	var canceled robot.ErrCanceled
+	var progSyntax program.ErrSyntax
+	var limit program.ErrStepLimit
+
+	if ve, ok := fieldErrors(err); ok {
+		rsp.Code = "validation_failed"
+		for _, fe := range ve {
+			f := RspErrorFromError(fe.Err)
+			rsp.Fields = append(rsp.Fields, rspFieldError{Field: fe.Field, Code: f.Code, Message: f.Message})
+		}
+		return rsp
+	}
+
+	switch {
+	case errors.As(err, &invalid):
+		rsp.Code, rsp.Index, rsp.Rune = "invalid_command", &invalid.Index, string(invalid.Rune)
+	case errors.As(err, &syntax):
+		rsp.Code, rsp.Index = "syntax_error", &syntax.Index
+	case errors.As(err, &progSyntax):
+		rsp.Code, rsp.Index = "syntax_error", &progSyntax.Index
+	case errors.As(err, &limit):
+		rsp.Code = "step_limit"
+	case errors.As(err, &collision):
+		rsp.Code, rsp.Step = "collision", &collision.Step
+	case errors.As(err, &energy):
+		rsp.Code, rsp.Step = "out_of_energy", &energy.Step
+	case errors.As(err, &canceled):
+		rsp.Code, rsp.Step = "canceled", &canceled.Step
+	default:
+		for _, ec := range errorCodes {
+			if errors.Is(err, ec.err) {
+				rsp.Code = ec.code
+				break
+			}
+		}
+	}
+
+	return rsp
+}
+
+// Wraps an error from decoding a request body. Invalid maps are reported as such rather than as invalid requests.
+func decodeError(err error) error {
+	if errors.Is(err, robot.ErrInvalidMap) {
+		return err
+	}
+	return fmt.Errorf("%w: %v", errInvalidRequest, err)
+}
+
+// Returns the status code for an error that kept a robot from executing commands: 409 if the robot is busy and 400 otherwise.
+func execStatus(err error) int {
+	if errors.Is(err, robot.ErrBusy) {
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
+
+// Writes the error as a JSON body with the given status code.
+func writeError(w http.ResponseWriter, statusCode int, err error) {
+	j, _ := json.Marshal(rspErrorBody{Error: RspErrorFromError(err)})
+
+	w.WriteHeader(statusCode)
+	io.WriteString(w, string(j))
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/anfly0/cuddly-octo-bassoon/internal/program"
+	"github.com/anfly0/cuddly-octo-bassoon/internal/robot"
+	"github.com/anfly0/cuddly-octo-bassoon/internal/storage"
+)
+
+func TestRspErrorFromError(t *testing.T) {
+	index, step := 3, 7
+
+	tests := []struct {
+		err  error
+		want rspError
+	}{
+		{robot.ErrInvalidCommand{Index: 3, Rune: 'X'}, rspError{Code: "invalid_command", Index: &index, Rune: "X"}},
+		{robot.ErrSyntax{Index: 3, Msg: "missing ']'"}, rspError{Code: "syntax_error", Index: &index}},
+		{robot.ErrCollision{Step: 7}, rspError{Code: "collision", Step: &step}},
+		{robot.ErrOutOfEnergy{Step: 7}, rspError{Code: "out_of_energy", Step: &step}},
+		{robot.ErrCanceled{Step: 7, Err: context.Canceled}, rspError{Code: "canceled", Step: &step}},
+		{program.ErrSyntax{Index: 3, Msg: "missing '}'"}, rspError{Code: "syntax_error", Index: &index}},
+		{program.ErrStepLimit{Limit: 100}, rspError{Code: "step_limit"}},
+		{program.ErrTooDeep, rspError{Code: "too_deep"}},
+		{robot.ErrTooManySteps, rspError{Code: "too_many_steps"}},
+		{robot.ErrOutsideRoom, rspError{Code: "outside_room"}},
+		{fmt.Errorf("%w %q", robot.ErrInvalidDirection, "X"), rspError{Code: "invalid_direction"}},
+		{fmt.Errorf("%w: abc", storage.ErrRoomNotFound), rspError{Code: "room_not_found"}},
+		{fmt.Errorf("%w: the target is blocked", robot.ErrUnreachable), rspError{Code: "unreachable"}},
+		{errors.New("something else"), rspError{Code: "error"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			got := RspErrorFromError(tt.err)
+
+			if got.Code != tt.want.Code || got.Message != tt.err.Error() || got.Rune != tt.want.Rune {
+				t.Errorf("got %+v want %+v", got, tt.want)
+			}
+			if (got.Index == nil) != (tt.want.Index == nil) || (got.Index != nil && *got.Index != *tt.want.Index) {
+				t.Errorf("got index %v want %v", got.Index, tt.want.Index)
+			}
+			if (got.Step == nil) != (tt.want.Step == nil) || (got.Step != nil && *got.Step != *tt.want.Step) {
+				t.Errorf("got step %v want %v", got.Step, tt.want.Step)
+			}
+		})
+	}
+}
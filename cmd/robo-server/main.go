@@ -1,51 +1,206 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/anfly0/cuddly-octo-bassoon/internal/program"
 	"github.com/anfly0/cuddly-octo-bassoon/internal/robot"
 	"github.com/anfly0/cuddly-octo-bassoon/internal/storage"
 	"github.com/anfly0/cuddly-octo-bassoon/internal/utils"
 )
 
+// Energy is the energy left in the battery of the robot and is only included if the robot has a battery.
 type rspStatus struct {
 	Direction string `json:"direction"`
 	X         uint   `json:"x"`
 	Y         uint   `json:"y"`
 	Id        string `json:"id"`
+	Energy    *uint  `json:"energy,omitempty"`
 }
 
 func RspStatusFromRobot(r *robot.Robot, id string) rspStatus {
 	d, coo := r.Report()
-	return rspStatus{Direction: string(d), X: coo.X, Y: coo.Y, Id: id}
+	rsp := rspStatus{Direction: d, X: coo.X, Y: coo.Y, Id: id}
+
+	if level, _, ok := r.Energy(); ok {
+		rsp.Energy = &level
+	}
+
+	return rsp
 }
 
 type reqCreate struct {
 	Direction string           `json:"direction"`
 	Room      robot.Room       `json:"room"`
 	Start     robot.Coordinate `json:"start"`
+	Compass   uint             `json:"compass,omitempty"`
+	Collision string           `json:"collision,omitempty"`
+	// The id of an existing room to create the robot in. Room must be left out if RoomId is set.
+	RoomId  string      `json:"room_id,omitempty"`
+	Battery *reqBattery `json:"battery,omitempty"`
+	Timing  *reqTiming  `json:"timing,omitempty"`
+}
+
+// The simulated time each command takes for robots that execute commands in the background.
+type reqTiming struct {
+	MoveMs uint `json:"move_ms"`
+	TurnMs uint `json:"turn_ms"`
+}
+
+// The battery of a robot. If Costs is left out every command costs 1.
+type reqBattery struct {
+	Capacity uint               `json:"capacity"`
+	Costs    *robot.EnergyCosts `json:"costs,omitempty"`
+}
+
+// The status of a robot together with the command strings that are queued for it and the latest ones that have finished, see RobotHandler.submit.
+type rspRobot struct {
+	rspStatus
+	Queue    []rspJob `json:"queue,omitempty"`
+	Finished []rspJob `json:"finished,omitempty"`
+}
+
+// A command string that is queued for a robot. Done is the number of steps that have been executed out of Steps.
+// End is the state of the robot after the job and is only set once the job has finished. If the job was aborted, Error describes why.
+type rspJob struct {
+	Id    string    `json:"id"`
+	JobId uint64    `json:"job_id"`
+	Cmd   string    `json:"cmd"`
+	Steps uint64    `json:"steps"`
+	Done  uint64    `json:"done"`
+	End   *rspPose  `json:"end,omitempty"`
+	Error *rspError `json:"error,omitempty"`
+}
+
+func RspJobFromJobStatus(j robot.JobStatus, id string) rspJob {
+	rsp := rspJob{Id: id, JobId: j.Id, Cmd: j.Cmd, Steps: j.Steps, Done: j.Done}
+	if j.Finished {
+		rsp.End = &rspPose{Direction: j.End.Direction, X: j.End.Coordinate.X, Y: j.End.Coordinate.Y}
+	}
+	if j.Err != nil {
+		rsp.Error = RspErrorFromError(j.Err)
+	}
+
+	return rsp
+}
+
+// The response to a command request. Bumps is the number of times the robot collided with a wall, a blocked cell or another robot and Collisions the index of every step that collided.
+// If the command string could not be executed to the end, Error describes why.
+// Trace is the state after every step and is only included if it was requested with trace=true.
+type rspCmd struct {
+	rspStatus
+	Bumps      int       `json:"bumps"`
+	Collisions []int     `json:"collisions"`
+	Trace      []rspPose `json:"trace,omitempty"`
+	Error      *rspError `json:"error,omitempty"`
+}
+
+type rspPose struct {
+	Direction string `json:"direction"`
+	X         uint   `json:"x"`
+	Y         uint   `json:"y"`
+}
+
+func RspCmdFromResult(res robot.Result, id string) rspCmd {
+	rsp := rspCmd{
+		rspStatus:  rspStatus{Direction: res.Direction, X: res.Coordinate.X, Y: res.Coordinate.Y, Id: id},
+		Bumps:      res.Bumps(),
+		Collisions: res.Collisions,
+	}
+	if rsp.Collisions == nil {
+		rsp.Collisions = []int{}
+	}
+	for _, p := range res.Trace {
+		rsp.Trace = append(rsp.Trace, rspPose{Direction: p.Direction, X: p.Coordinate.X, Y: p.Coordinate.Y})
+	}
+
+	return rsp
 }
 
 type reqCmd struct {
 	Cmd string `json:"cmd"`
 }
 
+// The response to a request for the events of a robot.
+type rspEvents struct {
+	Id     string        `json:"id"`
+	Events []robot.Event `json:"events"`
+}
+
 type RobotHandler struct {
-	store storage.RobotStore
+	store  storage.RobotStore
+	rooms  storage.RoomStore
+	events storage.EventLog
 }
 
+// Executes a command string. The execution is stopped if the client goes away before it is done. A text/plain body is streamed, see RobotHandler.stream.
 func (rh *RobotHandler) command(w http.ResponseWriter, r *http.Request) {
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt == "text/plain" {
+		rh.stream(w, r)
+		return
+	}
+
+	rh.execute(w, r, func(rb *robot.Robot, cs string, opts ...robot.CmdOption) (robot.Result, error) {
+		return rb.CmdContext(r.Context(), cs, opts...)
+	}, true)
+}
+
+// Predicts the result of a command request without moving the robot. The prediction is stopped if the client goes away before it is done.
+func (rh *RobotHandler) simulate(w http.ResponseWriter, r *http.Request) {
+	rh.execute(w, r, func(rb *robot.Robot, cs string, opts ...robot.CmdOption) (robot.Result, error) {
+		return rb.SimulateContext(r.Context(), cs, opts...)
+	}, false)
+}
+
+// Returns the options for executing a command string that are set with query parameters, i.e. trace.
+func cmdOptions(r *http.Request) ([]robot.CmdOption, error) {
+	var opts []robot.CmdOption
+
+	if t := r.URL.Query().Get("trace"); t != "" {
+		trace, err := strconv.ParseBool(t)
+		if err != nil {
+			return nil, fmt.Errorf("%w: trace must be true or false", errInvalidRequest)
+		}
+		if trace {
+			opts = append(opts, robot.WithTrace())
+		}
+	}
+
+	return opts, nil
+}
+
+/*
+Shared implementation of command and simulate. If the command string could not be executed to the end, the state of the robot is returned together with the error with a 400.
+If queue is true and the robot executes commands in simulated time, the command string is queued instead and a 202 is returned with the queued job.
+*/
+func (rh *RobotHandler) execute(w http.ResponseWriter, r *http.Request, op func(*robot.Robot, string, ...robot.CmdOption) (robot.Result, error), queue bool) {
+
+	opts, err := cmdOptions(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
 
 	req := reqCmd{}
 
-	err := json.NewDecoder(r.Body).Decode(&req)
+	err = json.NewDecoder(r.Body).Decode(&req)
 
-	if err != nil || req.Cmd == "" {
-		w.WriteHeader(http.StatusBadRequest)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, decodeError(err))
+		return
+	}
+
+	if req.Cmd == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("%w: cmd must not be empty", errInvalidRequest))
 		return
 	}
 
@@ -53,19 +208,316 @@ func (rh *RobotHandler) command(w http.ResponseWriter, r *http.Request) {
 	rb := rh.store.Get(id, r.Context())
 
 	if rb == nil {
-		w.WriteHeader(http.StatusNotFound)
+		writeError(w, http.StatusNotFound, fmt.Errorf("%w: %s", errRobotNotFound, id))
 		return
 	}
 
-	d, coo, err := rb.Cmd(req.Cmd)
+	if queue && rb.Timed() {
+		rh.submit(w, rb, id, req.Cmd, opts)
+		return
+	}
+
+	res, err := op(rb, req.Cmd, opts...)
+
+	rsp := RspCmdFromResult(res, id)
+
+	if err != nil {
+		rsp.Error = RspErrorFromError(err)
+		w.WriteHeader(execStatus(err))
+	}
 
-	rsp := rspStatus{Direction: string(d), X: coo.X, Y: coo.Y, Id: id}
 	j, _ := json.Marshal(rsp)
 
+	io.WriteString(w, string(j))
+}
+
+// The progress of a streamed command string. Steps is the number of steps that have been executed.
+type rspProgress struct {
+	Steps     int    `json:"steps"`
+	Direction string `json:"direction"`
+	X         uint   `json:"x"`
+	Y         uint   `json:"y"`
+}
+
+// The result of a streamed command string. Steps is the number of steps that were executed.
+type rspStream struct {
+	rspCmd
+	Steps int `json:"steps"`
+}
+
+/*
+Executes a command string that is streamed in the request body as it arrives. The response is streamed as well, as newline delimited JSON.
+Every time another n steps have been executed, where n is set with the progress query parameter, a line with the progress is written, and the last line is the result.
+If the command string could not be executed to the end, the result contains the error. The status code is 400 if that happened before any progress was written and 200 otherwise.
+*/
+func (rh *RobotHandler) stream(w http.ResponseWriter, r *http.Request) {
+
+	opts, err := cmdOptions(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	// The trace of a stream would grow with every step, however long the stream is.
+	if len(opts) > 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("%w: trace is not available for streamed command strings", errInvalidRequest))
+		return
+	}
+
+	every := defaultProgressInterval
+	if p := r.URL.Query().Get("progress"); p != "" {
+		if every, err = strconv.Atoi(p); err != nil || every < 1 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("%w: progress must be a positive integer", errInvalidRequest))
+			return
+		}
+	}
+
+	id := r.PathValue("id")
+	rb := rh.store.Get(id, r.Context())
+
+	if rb == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%w: %s", errRobotNotFound, id))
+		return
+	}
+
+	if rb.Timed() {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("%w: streaming is not available for robots with timing", errInvalidRequest))
+		return
+	}
+
+	// The body is read while the progress is written, which HTTP/1 servers do not allow by default.
+	rc := http.NewResponseController(w)
+	rc.EnableFullDuplex()
+	w.Header().Set("Content-Type", "application/x-ndjson")
+
+	written := false
+	enc := json.NewEncoder(w)
+	opts = append(opts, robot.WithProgress(every, func(p robot.Progress) {
+		written = true
+		enc.Encode(rspProgress{Steps: p.Steps, Direction: p.Direction, X: p.Coordinate.X, Y: p.Coordinate.Y})
+		rc.Flush()
+	}))
+
+	res, err := rb.CmdStream(r.Context(), r.Body, opts...)
+
+	rsp := rspStream{rspCmd: RspCmdFromResult(res, id), Steps: res.Steps}
+
+	if err != nil {
+		rsp.Error = RspErrorFromError(err)
+		if !written {
+			w.WriteHeader(execStatus(err))
+		}
+	}
+
+	enc.Encode(rsp)
+}
+
+// A request to move a robot to a target. If Execute is false the plan is only returned and the robot is not moved.
+type reqGoto struct {
+	Target      *robot.Coordinate `json:"target"`
+	FewestTurns bool              `json:"fewest_turns"`
+	Execute     bool              `json:"execute"`
+}
+
+// The response to a goto request. The state is the state after executing the plan, or the current state if the plan was not executed.
+// For robots that execute commands in simulated time the plan is queued instead of executed and Job is the queued job.
+type rspGoto struct {
+	rspCmd
+	Plan     string  `json:"plan"`
+	Executed bool    `json:"executed"`
+	Job      *rspJob `json:"job,omitempty"`
+}
+
+func (rh *RobotHandler) goTo(w http.ResponseWriter, r *http.Request) {
+
+	opts, err := cmdOptions(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	req := reqGoto{Execute: true}
+
+	err = json.NewDecoder(r.Body).Decode(&req)
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, decodeError(err))
+		return
+	}
+
+	if req.Target == nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("%w: target is required", errInvalidRequest))
+		return
+	}
+
+	id := r.PathValue("id")
+	rb := rh.store.Get(id, r.Context())
+
+	if rb == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%w: %s", errRobotNotFound, id))
+		return
+	}
+
+	rsp := rspGoto{Executed: req.Execute}
+	var res robot.Result
+
+	if req.Execute && rb.Timed() {
+		rh.submitPlan(w, rb, id, req, opts)
+		return
+	}
+
+	if req.Execute {
+		rsp.Plan, res, err = rb.Goto(*req.Target, req.FewestTurns, opts...)
+	} else {
+		rsp.Plan, err = rb.Plan(*req.Target, req.FewestTurns)
+		res.Direction, res.Coordinate = rb.Report()
+	}
+
+	if errors.Is(err, robot.ErrUnreachable) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rsp.rspCmd = RspCmdFromResult(res, id)
+
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		rsp.Error = RspErrorFromError(err)
+		w.WriteHeader(execStatus(err))
 	}
 
+	j, _ := json.Marshal(rsp)
+	io.WriteString(w, string(j))
+}
+
+// The response to a request for a sweep plan. Length is the number of commands in the plan.
+type rspSweep struct {
+	Id          string `json:"id"`
+	Plan        string `json:"plan"`
+	Length      int    `json:"length"`
+	Turns       int    `json:"turns"`
+	Covered     int    `json:"covered"`
+	Unreachable int    `json:"unreachable"`
+}
+
+func (rh *RobotHandler) getSweep(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	rb := rh.store.Get(id, r.Context())
+
+	if rb == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%w: %s", errRobotNotFound, id))
+		return
+	}
+
+	plan := rb.PlanSweep()
+	rsp := rspSweep{Id: id, Plan: plan.Cmds, Length: len(plan.Cmds), Turns: plan.Turns, Covered: plan.Covered, Unreachable: plan.Unreachable}
+
+	j, _ := json.Marshal(rsp)
+	io.WriteString(w, string(j))
+}
+
+// The response to a request for the coverage of a robot. Visits is the number of visits per cell, visits[y][x].
+type rspCoverage struct {
+	Id         string     `json:"id"`
+	Percentage float64    `json:"percentage"`
+	Free       int        `json:"free"`
+	Visited    int        `json:"visited"`
+	Visits     [][]uint32 `json:"visits"`
+}
+
+func (rh *RobotHandler) getCoverage(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	rb := rh.store.Get(id, r.Context())
+
+	if rb == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%w: %s", errRobotNotFound, id))
+		return
+	}
+
+	cov := rb.Coverage()
+	rsp := rspCoverage{Id: id, Percentage: cov.Percent(), Free: cov.Free, Visited: cov.Visited, Visits: cov.Visits}
+
+	j, _ := json.Marshal(rsp)
+	io.WriteString(w, string(j))
+}
+
+// A reading of a distance sensor. Hit is what the sensor detects: wall, obstacle, robot or nothing.
+type rspReading struct {
+	Distance uint   `json:"distance"`
+	Hit      string `json:"hit"`
+}
+
+// The response to a request for the sensor readings of a robot.
+type rspSensors struct {
+	Id    string     `json:"id"`
+	Front rspReading `json:"front"`
+	Left  rspReading `json:"left"`
+	Right rspReading `json:"right"`
+}
+
+func RspReadingFromReading(rd robot.Reading) rspReading {
+	return rspReading{Distance: rd.Distance, Hit: rd.Obstruction.String()}
+}
+
+func (rh *RobotHandler) getSensors(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	rb := rh.store.Get(id, r.Context())
+
+	if rb == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%w: %s", errRobotNotFound, id))
+		return
+	}
+
+	s := rb.Sense()
+	rsp := rspSensors{Id: id, Front: RspReadingFromReading(s.Front), Left: RspReadingFromReading(s.Left), Right: RspReadingFromReading(s.Right)}
+
+	j, _ := json.Marshal(rsp)
+	io.WriteString(w, string(j))
+}
+
+// Plans a route for a robot that executes commands in simulated time and queues the plan. Responds with a 202, the plan and the queued job, or with a 409 if the robot already has queued jobs.
+func (rh *RobotHandler) submitPlan(w http.ResponseWriter, rb *robot.Robot, id string, req reqGoto, opts []robot.CmdOption) {
+	if len(opts) > 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("%w: trace is not available for robots with timing", errInvalidRequest))
+		return
+	}
+
+	plan, job, err := rb.SubmitGoto(*req.Target, req.FewestTurns)
+	if err != nil {
+		writeError(w, execStatus(err), err)
+		return
+	}
+
+	rsp := rspGoto{rspCmd: rspCmd{rspStatus: RspStatusFromRobot(rb, id), Collisions: []int{}}, Plan: plan}
+
+	if plan != "" {
+		rj := RspJobFromJobStatus(job, id)
+		rsp.Job = &rj
+	}
+
+	j, _ := json.Marshal(rsp)
+	w.WriteHeader(http.StatusAccepted)
+	io.WriteString(w, string(j))
+}
+
+// Queues a command string for a robot that executes commands in simulated time and responds with a 202 and the queued job.
+func (rh *RobotHandler) submit(w http.ResponseWriter, rb *robot.Robot, id string, cs string, opts []robot.CmdOption) {
+	if len(opts) > 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("%w: trace is not available for robots with timing", errInvalidRequest))
+		return
+	}
+
+	job, err := rb.Submit(cs)
+	if err != nil {
+		writeError(w, execStatus(err), err)
+		return
+	}
+
+	rsp := RspJobFromJobStatus(job, id)
+
+	j, _ := json.Marshal(rsp)
+	w.WriteHeader(http.StatusAccepted)
 	io.WriteString(w, string(j))
 }
 
@@ -75,24 +527,54 @@ func (rh *RobotHandler) create(w http.ResponseWriter, r *http.Request) {
 
 	err := json.NewDecoder(r.Body).Decode(&req)
 
-	if err != nil || len(req.Direction) == 0 {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprint(w, err)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, decodeError(err))
 		return
 	}
 
-	rb, err := robot.NewRobot(req.Room, rune(req.Direction[0]), req.Start)
-
+	v, err := validateCreate(req)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprint(w, err)
+		writeError(w, http.StatusBadRequest, err)
 		return
 	}
 
 	id, err := utils.RandId(4)
 
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, errInternal)
+		return
+	}
+
+	opts := []robot.Option{robot.WithCompassMode(v.mode), robot.WithCollisionPolicy(v.policy)}
+	if req.Battery != nil {
+		costs := robot.EnergyCosts{F: 1, L: 1, R: 1}
+		if req.Battery.Costs != nil {
+			costs = *req.Battery.Costs
+		}
+		opts = append(opts, robot.WithBattery(req.Battery.Capacity, costs))
+	}
+	if req.Timing != nil {
+		opts = append(opts, robot.WithTiming(robot.Timing{
+			Move: time.Duration(req.Timing.MoveMs) * time.Millisecond,
+			Turn: time.Duration(req.Timing.TurnMs) * time.Millisecond,
+		}))
+	}
+	if rh.events != nil {
+		// The robot outlives the request, so the events are appended without the request context.
+		opts = append(opts, robot.WithEventSink(func(e robot.Event) {
+			rh.events.Append(id, e, context.Background())
+		}))
+	}
+	var rb *robot.Robot
+
+	if req.RoomId == "" {
+		rb, err = robot.NewRobot(req.Room, v.direction, req.Start, opts...)
+	} else {
+		rb, err = rh.createInRoom(req, v.direction, id, opts, r.Context())
+	}
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
 		return
 	}
 
@@ -104,13 +586,78 @@ func (rh *RobotHandler) create(w http.ResponseWriter, r *http.Request) {
 	io.WriteString(w, string(j))
 }
 
+// Creates a robot in an existing shared room and records it as a member of the room.
+func (rh *RobotHandler) createInRoom(req reqCreate, d string, id string, opts []robot.Option, ctx context.Context) (*robot.Robot, error) {
+	var sr *robot.SharedRoom
+	if rh.rooms != nil {
+		sr = rh.rooms.Get(req.RoomId, ctx)
+	}
+
+	if sr == nil {
+		return nil, fmt.Errorf("%w: %s", storage.ErrRoomNotFound, req.RoomId)
+	}
+
+	rb, err := sr.NewRobot(d, req.Start, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return rb, rh.rooms.Join(req.RoomId, id, ctx)
+}
+
 func (rh *RobotHandler) getStatus(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 
 	rb := rh.store.Get(id, r.Context())
 
 	if rb == nil {
-		w.WriteHeader(http.StatusNotFound)
+		writeError(w, http.StatusNotFound, fmt.Errorf("%w: %s", errRobotNotFound, id))
+		return
+	}
+
+	rsp := rspRobot{rspStatus: RspStatusFromRobot(rb, id)}
+	for _, job := range rb.Queue() {
+		rsp.Queue = append(rsp.Queue, RspJobFromJobStatus(job, id))
+	}
+	for _, job := range rb.Finished() {
+		rsp.Finished = append(rsp.Finished, RspJobFromJobStatus(job, id))
+	}
+
+	j, _ := json.Marshal(rsp)
+	io.WriteString(w, string(j))
+}
+
+func (rh *RobotHandler) getEvents(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	rb := rh.store.Get(id, r.Context())
+
+	if rb == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%w: %s", errRobotNotFound, id))
+		return
+	}
+
+	rsp := rspEvents{Id: id, Events: []robot.Event{}}
+	if rh.events != nil {
+		rsp.Events = append(rsp.Events, rh.events.Events(id, r.Context())...)
+	}
+
+	j, _ := json.Marshal(rsp)
+	io.WriteString(w, string(j))
+}
+
+func (rh *RobotHandler) charge(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	rb := rh.store.Get(id, r.Context())
+
+	if rb == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%w: %s", errRobotNotFound, id))
+		return
+	}
+
+	if _, err := rb.Charge(); err != nil {
+		writeError(w, http.StatusConflict, err)
 		return
 	}
 
@@ -120,16 +667,199 @@ func (rh *RobotHandler) getStatus(w http.ResponseWriter, r *http.Request) {
 	io.WriteString(w, string(j))
 }
 
+// A request to run a program on a robot. If Limit is left out the default step limit is used.
+type reqProgram struct {
+	Program string `json:"program"`
+	Limit   *int   `json:"limit,omitempty"`
+}
+
+// The response to a program request. Steps is the number of commands the robot executed.
+type rspProgram struct {
+	rspCmd
+	Steps int `json:"steps"`
+}
+
+/*
+Runs a program on a robot, see the program package for the language. Programs are always run right away, also on robots that execute commands in simulated time.
+If the program could not be run to the end, the state of the robot is returned together with the error with a 400.
+*/
+func (rh *RobotHandler) runProgram(w http.ResponseWriter, r *http.Request) {
+
+	opts, err := cmdOptions(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	req := reqProgram{}
+
+	err = json.NewDecoder(r.Body).Decode(&req)
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, decodeError(err))
+		return
+	}
+
+	limit := defaultStepLimit
+	if req.Limit != nil {
+		limit = *req.Limit
+	}
+	if limit < 1 || limit > maxStepLimit {
+		writeError(w, http.StatusBadRequest, validationError{{"limit", errInvalidLimit}})
+		return
+	}
+
+	prog, err := program.Parse(req.Program)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	id := r.PathValue("id")
+	rb := rh.store.Get(id, r.Context())
+
+	if rb == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%w: %s", errRobotNotFound, id))
+		return
+	}
+
+	res, err := prog.Run(rb, limit, opts...)
+
+	rsp := rspProgram{rspCmd: RspCmdFromResult(res, id), Steps: res.Steps}
+
+	if err != nil {
+		rsp.Error = RspErrorFromError(err)
+		w.WriteHeader(execStatus(err))
+	}
+
+	j, _ := json.Marshal(rsp)
+	io.WriteString(w, string(j))
+}
+
+/*
+A request to optimize a command string. Compass is the compass of the robot the command string is meant for, 4 or 8 points.
+If RobotId is set the command string is optimized for the current pose of that robot instead and Compass must be left out.
+*/
+type reqOptimize struct {
+	Cmd     string `json:"cmd"`
+	Compass uint   `json:"compass,omitempty"`
+	RobotId string `json:"robot_id,omitempty"`
+}
+
+// The response to an optimize request. Savings is the number of steps that were saved.
+type rspOptimize struct {
+	Cmd            string `json:"cmd"`
+	Optimized      string `json:"optimized"`
+	Steps          uint64 `json:"steps"`
+	OptimizedSteps uint64 `json:"optimized_steps"`
+	Savings        uint64 `json:"savings"`
+}
+
+func (rh *RobotHandler) optimize(w http.ResponseWriter, r *http.Request) {
+
+	req := reqOptimize{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, decodeError(err))
+		return
+	}
+
+	var opt robot.Optimization
+
+	switch {
+	case req.RobotId != "" && req.Compass != 0:
+		writeError(w, http.StatusBadRequest, validationError{{"compass", fmt.Errorf("%w: a request can not have both a compass and a robot id", errInvalidRequest)}})
+		return
+	case req.RobotId != "":
+		rb := rh.store.Get(req.RobotId, r.Context())
+		if rb == nil {
+			writeError(w, http.StatusNotFound, fmt.Errorf("%w: %s", errRobotNotFound, req.RobotId))
+			return
+		}
+		opt, err = rb.Optimize(req.Cmd)
+	case req.Compass == 0 || req.Compass == 4:
+		opt, err = robot.Optimize(req.Cmd, robot.FourPoint)
+	case req.Compass == 8:
+		opt, err = robot.Optimize(req.Cmd, robot.EightPoint)
+	default:
+		writeError(w, http.StatusBadRequest, validationError{{"compass", errInvalidCompass}})
+		return
+	}
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rsp := rspOptimize{Cmd: req.Cmd, Optimized: opt.Cmds, Steps: opt.Steps, OptimizedSteps: opt.OptimizedSteps, Savings: opt.Saved()}
+
+	j, _ := json.Marshal(rsp)
+	io.WriteString(w, string(j))
+}
+
+func (rh *RobotHandler) undo(w http.ResponseWriter, r *http.Request) {
+	rh.history(w, r, (*robot.Robot).Undo)
+}
+
+func (rh *RobotHandler) redo(w http.ResponseWriter, r *http.Request) {
+	rh.history(w, r, (*robot.Robot).Redo)
+}
+
+// Shared implementation of undo and redo. If there is nothing to undo/redo, or the restored cell is occupied, a 409 is returned.
+func (rh *RobotHandler) history(w http.ResponseWriter, r *http.Request, op func(*robot.Robot) (string, robot.Coordinate, error)) {
+	id := r.PathValue("id")
+
+	rb := rh.store.Get(id, r.Context())
+
+	if rb == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%w: %s", errRobotNotFound, id))
+		return
+	}
+
+	d, coo, err := op(rb)
+
+	if err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+
+	rsp := rspStatus{Direction: d, X: coo.X, Y: coo.Y, Id: id}
+
+	j, _ := json.Marshal(rsp)
+	io.WriteString(w, string(j))
+}
+
 func main() {
 
 	addr := flag.String("addr", "", "Ip address the server will listen to")
 	port := flag.String("port", "8080", "Port number the server will listen to")
 	flag.Parse()
-	rh := RobotHandler{store: storage.NewRobotMemStore()}
+	robots := storage.NewRobotMemStore()
+	rooms := storage.NewRoomMemStore()
+	events := storage.NewEventMemLog()
+	rh := RobotHandler{store: robots, rooms: rooms, events: events}
+	roomh := RoomHandler{store: rooms, robots: robots}
 
 	http.Handle("POST /robot", Chain(http.HandlerFunc(rh.create), Logging, ContentHeader))
 	http.Handle("GET /robot/{id}", Chain(http.HandlerFunc(rh.getStatus), Logging, ContentHeader))
 	http.Handle("POST /robot/{id}", Chain(http.HandlerFunc(rh.command), Logging, ContentHeader))
+	http.Handle("POST /robot/{id}/simulate", Chain(http.HandlerFunc(rh.simulate), Logging, ContentHeader))
+	http.Handle("POST /robot/{id}/goto", Chain(http.HandlerFunc(rh.goTo), Logging, ContentHeader))
+	http.Handle("GET /robot/{id}/sweep", Chain(http.HandlerFunc(rh.getSweep), Logging, ContentHeader))
+	http.Handle("GET /robot/{id}/coverage", Chain(http.HandlerFunc(rh.getCoverage), Logging, ContentHeader))
+	http.Handle("GET /robot/{id}/sensors", Chain(http.HandlerFunc(rh.getSensors), Logging, ContentHeader))
+	http.Handle("POST /robot/{id}/program", Chain(http.HandlerFunc(rh.runProgram), Logging, ContentHeader))
+	http.Handle("GET /robot/{id}/events", Chain(http.HandlerFunc(rh.getEvents), Logging, ContentHeader))
+	http.Handle("POST /robot/{id}/charge", Chain(http.HandlerFunc(rh.charge), Logging, ContentHeader))
+	http.Handle("POST /robot/{id}/undo", Chain(http.HandlerFunc(rh.undo), Logging, ContentHeader))
+	http.Handle("POST /robot/{id}/redo", Chain(http.HandlerFunc(rh.redo), Logging, ContentHeader))
+	http.Handle("POST /commands/optimize", Chain(http.HandlerFunc(rh.optimize), Logging, ContentHeader))
+	http.Handle("POST /room", Chain(http.HandlerFunc(roomh.create), Logging, ContentHeader))
+	http.Handle("GET /room/{id}", Chain(http.HandlerFunc(roomh.get), Logging, ContentHeader))
+	http.Handle("GET /room/{id}/robots", Chain(http.HandlerFunc(roomh.getRobots), Logging, ContentHeader))
+	http.Handle("DELETE /room/{id}", Chain(http.HandlerFunc(roomh.delete), Logging, ContentHeader))
 
 	s_addr := fmt.Sprintf("%s:%s", *addr, *port)
 	fmt.Printf("Starting server on %s!", s_addr)
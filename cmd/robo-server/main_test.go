@@ -4,11 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/anfly0/cuddly-octo-bassoon/internal/robot"
 	"github.com/anfly0/cuddly-octo-bassoon/internal/storage"
@@ -29,12 +31,18 @@ func TestRobotHandler_create(t *testing.T) {
 	voidStore := &robotVoidStore{}
 	robotHandler := RobotHandler{store: voidStore}
 
+	lShaped, err := robot.NewGridMap([]string{"..#", "..#", "..."})
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	type args struct {
 		body reqCreate
 	}
 
 	type rsp struct {
-		code int
+		code    int
+		errCode string
 		//TODO: add expected body to make the test cases more complete.
 	}
 	tests := []struct {
@@ -50,18 +58,52 @@ func TestRobotHandler_create(t *testing.T) {
 		{
 			name: "Create invalid robot",
 			args: args{body: reqCreate{Direction: "N", Room: robot.Room{X: 1, Y: 1}, Start: robot.Coordinate{X: 1, Y: 1}}},
-			want: rsp{code: http.StatusBadRequest},
+			want: rsp{code: http.StatusBadRequest, errCode: "outside_room"},
 		},
 		{
 			name: "Create robot with invalid direction",
 			args: args{body: reqCreate{Direction: "", Room: robot.Room{X: 1, Y: 1}, Start: robot.Coordinate{X: 0, Y: 0}}},
-			want: rsp{code: http.StatusBadRequest},
+			want: rsp{code: http.StatusBadRequest, errCode: "invalid_direction"},
+		},
+		{
+			name: "Create robot on an obstacle",
+			args: args{body: reqCreate{Direction: "N", Room: robot.Room{X: 2, Y: 2, Obstacles: []robot.Coordinate{{X: 0, Y: 0}}}, Start: robot.Coordinate{X: 0, Y: 0}}},
+			want: rsp{code: http.StatusBadRequest, errCode: "blocked_cell"},
+		},
+		{
+			name: "Create robot in a map room",
+			args: args{body: reqCreate{Direction: "N", Room: robot.Room{Map: lShaped}, Start: robot.Coordinate{X: 1, Y: 1}}},
+			want: rsp{code: http.StatusOK},
+		},
+		{
+			name: "Create robot on a blocked map cell",
+			args: args{body: reqCreate{Direction: "N", Room: robot.Room{Map: lShaped}, Start: robot.Coordinate{X: 2, Y: 1}}},
+			want: rsp{code: http.StatusBadRequest, errCode: "blocked_cell"},
+		},
+		{
+			name: "Create robot with an eight-point compass",
+			args: args{body: reqCreate{Direction: "NE", Compass: 8, Room: robot.Room{X: 1, Y: 1}, Start: robot.Coordinate{X: 0, Y: 0}}},
+			want: rsp{code: http.StatusOK},
+		},
+		{
+			name: "Create robot with an invalid compass",
+			args: args{body: reqCreate{Direction: "N", Compass: 6, Room: robot.Room{X: 1, Y: 1}, Start: robot.Coordinate{X: 0, Y: 0}}},
+			want: rsp{code: http.StatusBadRequest, errCode: "invalid_compass"},
+		},
+		{
+			name: "Create robot with a collision policy",
+			args: args{body: reqCreate{Direction: "N", Collision: "wrap", Room: robot.Room{X: 1, Y: 1}, Start: robot.Coordinate{X: 0, Y: 0}}},
+			want: rsp{code: http.StatusOK},
+		},
+		{
+			name: "Create robot with an invalid collision policy",
+			args: args{body: reqCreate{Direction: "N", Collision: "bounce", Room: robot.Room{X: 1, Y: 1}, Start: robot.Coordinate{X: 0, Y: 0}}},
+			want: rsp{code: http.StatusBadRequest, errCode: "invalid_collision_policy"},
 		},
 		{
 			name: "Create robot with invalid room",
 			args: args{body: reqCreate{Direction: "N", Room: robot.Room{X: 0, Y: 0}, Start: robot.Coordinate{X: 0, Y: 0}}},
-			want: rsp{code: http.StatusBadRequest},
-			// TODO: This test is passing because all possible robot starting positions are "outside" the room. There should be a check when crating the room to give a better error meg.
+			want: rsp{code: http.StatusBadRequest, errCode: "empty_room"},
 		},
 	}
 
@@ -89,6 +131,128 @@ func TestRobotHandler_create(t *testing.T) {
 			if rr.Result().StatusCode != tt.want.code {
 				t.Errorf("wrong status code: got %v want %v", rr.Code, tt.want.code)
 			}
+
+			if tt.want.errCode != "" && !hasErrorCode(rr.Body.Bytes(), tt.want.errCode) {
+				t.Errorf("wrong error body: got %s want code %s", rr.Body.String(), tt.want.errCode)
+			}
+		})
+	}
+}
+
+// Returns true if the body is an error with the code, or a validation error with a field error with the code.
+func hasErrorCode(b []byte, code string) bool {
+	body := rspErrorBody{}
+	if err := json.Unmarshal(b, &body); err != nil || body.Error == nil {
+		return false
+	}
+
+	if body.Error.Code == code {
+		return true
+	}
+	for _, f := range body.Error.Fields {
+		if f.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRobotHandler_createValidation(t *testing.T) {
+
+	robotHandler := RobotHandler{store: &robotVoidStore{}}
+
+	tests := []struct {
+		name   string
+		body   string
+		code   int
+		fields map[string]string
+	}{
+		{
+			name: "Direction as a full word",
+			body: `{"direction": "East", "room": {"x": 2, "y": 2}}`,
+			code: http.StatusOK,
+		},
+		{
+			name: "Direction in degrees",
+			body: `{"direction": "225", "compass": 8, "room": {"x": 2, "y": 2}}`,
+			code: http.StatusOK,
+		},
+		{
+			name:   "Unknown direction",
+			body:   `{"direction": "Xyz", "room": {"x": 2, "y": 2}}`,
+			code:   http.StatusBadRequest,
+			fields: map[string]string{"direction": "invalid_direction"},
+		},
+		{
+			name:   "Oversized room",
+			body:   `{"direction": "N", "room": {"x": 1001, "y": 2}}`,
+			code:   http.StatusBadRequest,
+			fields: map[string]string{"room.x": "room_too_large"},
+		},
+		{
+			name:   "Room and room id",
+			body:   `{"direction": "N", "room_id": "abc", "room": {"x": 2, "y": 2}}`,
+			code:   http.StatusBadRequest,
+			fields: map[string]string{"room": "invalid_request"},
+		},
+		{
+			name:   "All errors at once",
+			body:   `{"direction": "NE", "compass": 6, "collision": "bounce", "room": {"x": 0, "y": 5000}}`,
+			code:   http.StatusBadRequest,
+			fields: map[string]string{"direction": "invalid_direction", "compass": "invalid_compass", "collision": "invalid_collision_policy", "room.x": "empty_room", "room.y": "room_too_large"},
+		},
+		{
+			name:   "Empty battery",
+			body:   `{"direction": "N", "room": {"x": 2, "y": 2}, "battery": {"capacity": 0}}`,
+			code:   http.StatusBadRequest,
+			fields: map[string]string{"battery.capacity": "invalid_battery"},
+		},
+		{
+			name:   "Start outside the room",
+			body:   `{"direction": "N", "room": {"x": 2, "y": 2}, "start": {"x": 0, "y": 2}}`,
+			code:   http.StatusBadRequest,
+			fields: map[string]string{"start": "outside_room"},
+		},
+		{
+			name:   "Obstacles and chargers outside the room or blocked",
+			body:   `{"direction": "N", "room": {"x": 3, "y": 3, "obstacles": [{"x": 1, "y": 1}, {"x": 3, "y": 0}], "chargers": [{"x": 0, "y": 0}, {"x": 0, "y": 3}, {"x": 1, "y": 1}]}}`,
+			code:   http.StatusBadRequest,
+			fields: map[string]string{"room.obstacles[1]": "outside_room", "room.chargers[1]": "outside_room", "room.chargers[2]": "blocked_cell"},
+		},
+		{
+			name:   "Charger on a blocked map cell",
+			body:   `{"direction": "N", "room": {"map": [".#", ".."], "chargers": [{"x": 1, "y": 0}]}}`,
+			code:   http.StatusBadRequest,
+			fields: map[string]string{"room.chargers[0]": "blocked_cell"},
+		},
+		{
+			name:   "Commands that take too long",
+			body:   `{"direction": "N", "room": {"x": 2, "y": 2}, "timing": {"move_ms": 1001, "turn_ms": 1000}}`,
+			code:   http.StatusBadRequest,
+			fields: map[string]string{"timing.move_ms": "invalid_timing"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := serve(t, robotHandler.create, "POST", "/robot", "", tt.body)
+
+			if rr.Code != tt.code {
+				t.Fatalf("wrong status code: got %v want %v %s", rr.Code, tt.code, rr.Body.String())
+			}
+			if tt.fields == nil {
+				return
+			}
+
+			body := rspErrorBody{}
+			json.Unmarshal(rr.Body.Bytes(), &body)
+			got := map[string]string{}
+			for _, f := range body.Error.Fields {
+				got[f.Field] = f.Code
+			}
+			if body.Error.Code != "validation_failed" || !reflect.DeepEqual(got, tt.fields) {
+				t.Errorf("wrong field errors: got %v want %v", got, tt.fields)
+			}
 		})
 	}
 }
@@ -103,7 +267,7 @@ func TestRobotHandler_getStatus(t *testing.T) {
 		reqId   string
 		room    robot.Room
 		coo     robot.Coordinate
-		d       rune
+		d       string
 	}
 
 	type rsp struct {
@@ -117,12 +281,12 @@ func TestRobotHandler_getStatus(t *testing.T) {
 		// Make sure all test cases has a uniq id for the robot. All test cases share the same robot store.
 		{
 			name: "Get a robot that is in the store",
-			args: args{robotId: "abc", reqId: "abc", room: robot.Room{X: 1, Y: 1}, coo: robot.Coordinate{X: 0, Y: 0}, d: 'N'},
+			args: args{robotId: "abc", reqId: "abc", room: robot.Room{X: 1, Y: 1}, coo: robot.Coordinate{X: 0, Y: 0}, d: "N"},
 			want: rsp{code: http.StatusOK},
 		},
 		{
 			name: "Get a robot that is not in the store",
-			args: args{robotId: "abc", reqId: "abcd", room: robot.Room{X: 1, Y: 1}, coo: robot.Coordinate{X: 0, Y: 0}, d: 'N'},
+			args: args{robotId: "abc", reqId: "abcd", room: robot.Room{X: 1, Y: 1}, coo: robot.Coordinate{X: 0, Y: 0}, d: "N"},
 			want: rsp{code: http.StatusNotFound},
 		},
 	}
@@ -167,8 +331,9 @@ func TestRobotHandler_command(t *testing.T) {
 		reqId   string
 		room    robot.Room
 		coo     robot.Coordinate
-		d       rune
+		d       string
 		cmd     string
+		opts    []robot.Option
 	}
 
 	type rsp struct {
@@ -183,18 +348,33 @@ func TestRobotHandler_command(t *testing.T) {
 		// Make sure all test cases has a uniq id for the robot (robotId). All test cases share the same robot store.
 		{
 			name: "Command a robot that is in the store",
-			args: args{robotId: "abc", reqId: "abc", room: robot.Room{X: 5, Y: 5}, coo: robot.Coordinate{X: 1, Y: 2}, d: 'N', cmd: "RFRFFRFRF"},
+			args: args{robotId: "abc", reqId: "abc", room: robot.Room{X: 5, Y: 5}, coo: robot.Coordinate{X: 1, Y: 2}, d: "N", cmd: "RFRFFRFRF"},
 			want: rsp{code: http.StatusOK, status: rspStatus{Direction: "N", X: 1, Y: 3, Id: "abc"}},
 		},
 		{
 			name: "Command a robot that is not in the store",
-			args: args{robotId: "abc", reqId: "abcd", room: robot.Room{X: 5, Y: 5}, coo: robot.Coordinate{X: 1, Y: 2}, d: 'N', cmd: "RFRFFRFRF"},
+			args: args{robotId: "abc", reqId: "abcd", room: robot.Room{X: 5, Y: 5}, coo: robot.Coordinate{X: 1, Y: 2}, d: "N", cmd: "RFRFFRFRF"},
 			want: rsp{code: http.StatusNotFound, status: rspStatus{}},
 		},
 		{
 			name: "Command a robot with an invalid command string",
-			args: args{robotId: "abc", reqId: "abc", room: robot.Room{X: 5, Y: 5}, coo: robot.Coordinate{X: 1, Y: 2}, d: 'N', cmd: "RFRFFRFRFAFFFF"},
-			want: rsp{code: http.StatusBadRequest, status: rspStatus{Direction: "N", X: 1, Y: 3, Id: "abc"}},
+			args: args{robotId: "abc", reqId: "abc", room: robot.Room{X: 5, Y: 5}, coo: robot.Coordinate{X: 1, Y: 2}, d: "N", cmd: "RFRFFRFRFAFFFF"},
+			want: rsp{code: http.StatusBadRequest, status: rspStatus{Direction: "N", X: 1, Y: 2, Id: "abc"}},
+		},
+		{
+			name: "Command a robot with repeats and groups",
+			args: args{robotId: "abc", reqId: "abc", room: robot.Room{X: 5, Y: 5}, coo: robot.Coordinate{X: 0, Y: 0}, d: "E", cmd: "2[2FR]"},
+			want: rsp{code: http.StatusOK, status: rspStatus{Direction: "W", X: 2, Y: 2, Id: "abc"}},
+		},
+		{
+			name: "Command a robot with an unterminated group",
+			args: args{robotId: "abc", reqId: "abc", room: robot.Room{X: 5, Y: 5}, coo: robot.Coordinate{X: 0, Y: 0}, d: "E", cmd: "2FR3[F"},
+			want: rsp{code: http.StatusBadRequest, status: rspStatus{Direction: "E", X: 0, Y: 0, Id: "abc"}},
+		},
+		{
+			name: "Command a robot that stops at the first collision",
+			args: args{robotId: "abc", reqId: "abc", room: robot.Room{X: 5, Y: 5}, coo: robot.Coordinate{X: 3, Y: 0}, d: "E", cmd: "FFFRF", opts: []robot.Option{robot.WithCollisionPolicy(robot.Stop)}},
+			want: rsp{code: http.StatusBadRequest, status: rspStatus{Direction: "E", X: 4, Y: 0, Id: "abc"}},
 		},
 	}
 
@@ -217,7 +397,7 @@ func TestRobotHandler_command(t *testing.T) {
 			rr := httptest.NewRecorder()
 			handler := http.HandlerFunc(robotHandler.command)
 
-			r, err := robot.NewRobot(tt.args.room, tt.args.d, tt.args.coo)
+			r, err := robot.NewRobot(tt.args.room, tt.args.d, tt.args.coo, tt.args.opts...)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -243,6 +423,775 @@ func TestRobotHandler_command(t *testing.T) {
 	}
 }
 
+func TestRobotHandler_commandBumps(t *testing.T) {
+
+	robotStore := storage.NewRobotMemStore()
+	robotHandler := RobotHandler{store: robotStore}
+
+	r, err := robot.NewRobot(robot.Room{X: 2, Y: 2}, "N", robot.Coordinate{X: 0, Y: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	robotStore.Put("abc", r, context.Background())
+
+	req, err := http.NewRequest("POST", "/robot/abc", strings.NewReader(`{"cmd": "FFRFF"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.SetPathValue("id", "abc")
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(robotHandler.command).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	rsp := rspCmd{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &rsp); err != nil {
+		t.Fatal(err)
+	}
+
+	if rsp.Bumps != 2 || !reflect.DeepEqual(rsp.Collisions, []int{1, 4}) {
+		t.Errorf("Got bumps %d collisions %v, want 2 [1 4]", rsp.Bumps, rsp.Collisions)
+	}
+}
+
+func TestRobotHandler_commandStream(t *testing.T) {
+
+	robotStore := storage.NewRobotMemStore()
+	robotHandler := RobotHandler{store: robotStore}
+
+	r, err := robot.NewRobot(robot.Room{X: 3, Y: 3}, "N", robot.Coordinate{X: 0, Y: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	robotStore.Put("abc", r, context.Background())
+
+	tests := []struct {
+		name  string
+		path  string
+		body  string
+		code  int
+		lines []string
+	}{
+		{
+			"Stream", "/robot/abc?progress=2", "FF\nR 2[F]\n", http.StatusOK,
+			[]string{
+				`{"steps":2,"direction":"N","x":0,"y":0}`,
+				`{"steps":4,"direction":"E","x":1,"y":0}`,
+				`{"direction":"E","x":2,"y":0,"id":"abc","bumps":0,"collisions":[],"steps":5}`,
+			},
+		},
+		{
+			"Error after progress", "/robot/abc?progress=1", "LF X", http.StatusOK,
+			[]string{
+				`{"steps":1,"direction":"N","x":2,"y":0}`,
+				`{"steps":2,"direction":"N","x":2,"y":0}`,
+				`{"direction":"N","x":2,"y":0,"id":"abc","bumps":1,"collisions":[1],"error":{"code":"invalid_command","message":"invalid command 'X' at position 3, valid commands are L, R, F, B, \u003c, \u003e","index":3,"rune":"X"},"steps":2}`,
+			},
+		},
+		{
+			"Error before progress", "/robot/abc", "RX", http.StatusBadRequest,
+			[]string{
+				`{"direction":"E","x":2,"y":0,"id":"abc","bumps":0,"collisions":[],"error":{"code":"invalid_command","message":"invalid command 'X' at position 1, valid commands are L, R, F, B, \u003c, \u003e","index":1,"rune":"X"},"steps":1}`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("POST", tt.path, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+			req.SetPathValue("id", "abc")
+
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(robotHandler.command).ServeHTTP(rr, req)
+
+			lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+			if rr.Code != tt.code || !reflect.DeepEqual(lines, tt.lines) {
+				t.Errorf("wrong response: got %v %q want %v %q", rr.Code, lines, tt.code, tt.lines)
+			}
+		})
+	}
+
+	// An invalid progress interval is rejected, and so is a trace, which would grow with every step of the stream.
+	for _, path := range []string{"/robot/abc?progress=0", "/robot/abc?trace=true"} {
+		req, err := http.NewRequest("POST", path, strings.NewReader("F"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "text/plain")
+		req.SetPathValue("id", "abc")
+
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(robotHandler.command).ServeHTTP(rr, req)
+		if rr.Code != http.StatusBadRequest || !hasErrorCode(rr.Body.Bytes(), "invalid_request") {
+			t.Errorf("wrong response for %s: got %v %s", path, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestRobotHandler_commandCanceled(t *testing.T) {
+
+	robotStore := storage.NewRobotMemStore()
+	robotHandler := RobotHandler{store: robotStore}
+
+	r, err := robot.NewRobot(robot.Room{X: 2, Y: 2}, "N", robot.Coordinate{X: 0, Y: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	robotStore.Put("abc", r, context.Background())
+
+	// The client has gone away before the command string is executed.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", "/robot/abc", strings.NewReader(`{"cmd": "FFRFF"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.SetPathValue("id", "abc")
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(robotHandler.command).ServeHTTP(rr, req)
+
+	rsp := rspCmd{}
+	json.Unmarshal(rr.Body.Bytes(), &rsp)
+
+	if rr.Code != http.StatusBadRequest || rsp.Error == nil || rsp.Error.Code != "canceled" || *rsp.Error.Step != 0 {
+		t.Errorf("wrong response: got %v %s", rr.Code, rr.Body.String())
+	}
+	if d, c := r.Report(); d != "N" || c != (robot.Coordinate{X: 0, Y: 1}) {
+		t.Errorf("the robot moved to %s %+v", d, c)
+	}
+}
+
+func TestRobotHandler_commandBusy(t *testing.T) {
+
+	robotStore := storage.NewRobotMemStore()
+	robotHandler := RobotHandler{store: robotStore}
+
+	r, err := robot.NewRobot(robot.Room{X: 3, Y: 3}, "N", robot.Coordinate{X: 0, Y: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	robotStore.Put("abc", r, context.Background())
+
+	// The robot is busy while a command string is streamed to it.
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.CmdStream(context.Background(), pr)
+	}()
+	pw.Write([]byte("F"))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for _, c := r.Report(); c != (robot.Coordinate{X: 0, Y: 1}); _, c = r.Report() {
+		if time.Now().After(deadline) {
+			t.Fatal("the stream was not executed in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		handler http.HandlerFunc
+		path    string
+		body    string
+	}{
+		{"Command", robotHandler.command, "/robot/abc", `{"cmd": "F"}`},
+		{"Goto", robotHandler.goTo, "/robot/abc/goto", `{"target": {"x": 0, "y": 0}, "execute": true}`},
+		{"Undo", robotHandler.undo, "/robot/abc/undo", ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := serve(t, tt.handler, "POST", tt.path, "abc", tt.body)
+			if rr.Code != http.StatusConflict || !hasErrorCode(rr.Body.Bytes(), "busy") {
+				t.Errorf("wrong response: got %v %s", rr.Code, rr.Body.String())
+			}
+		})
+	}
+
+	pw.Close()
+	<-done
+}
+
+func TestRobotHandler_commandTrace(t *testing.T) {
+
+	robotStore := storage.NewRobotMemStore()
+	robotHandler := RobotHandler{store: robotStore}
+
+	r, err := robot.NewRobot(robot.Room{X: 2, Y: 2}, "N", robot.Coordinate{X: 0, Y: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	robotStore.Put("abc", r, context.Background())
+
+	tests := []struct {
+		name  string
+		query string
+		code  int
+		trace []rspPose
+	}{
+		{"Without trace", "", http.StatusOK, nil},
+		{"Trace false", "?trace=false", http.StatusOK, nil},
+		{"Trace", "?trace=true", http.StatusOK, []rspPose{{"E", 0, 1}, {"E", 1, 1}, {"E", 1, 1}}},
+		{"Invalid trace", "?trace=yes", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := serve(t, robotHandler.command, "POST", "/robot/abc"+tt.query, "abc", `{"cmd": "RFF"}`)
+			r.Undo()
+
+			if rr.Code != tt.code {
+				t.Errorf("wrong status code: got %v want %v", rr.Code, tt.code)
+			}
+
+			rsp := rspCmd{}
+			json.Unmarshal(rr.Body.Bytes(), &rsp)
+			if !reflect.DeepEqual(rsp.Trace, tt.trace) {
+				t.Errorf("wrong trace: got %v want %v", rsp.Trace, tt.trace)
+			}
+		})
+	}
+}
+
+func TestRobotHandler_goTo(t *testing.T) {
+
+	robotStore := storage.NewRobotMemStore()
+	robotHandler := RobotHandler{store: robotStore}
+
+	r, err := robot.NewRobot(robot.Room{X: 3, Y: 3, Obstacles: []robot.Coordinate{{X: 1, Y: 1}}}, "N", robot.Coordinate{X: 0, Y: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	robotStore.Put("abc", r, context.Background())
+
+	tests := []struct {
+		name    string
+		body    string
+		code    int
+		rsp     rspGoto
+		errCode string
+	}{
+		{"Plan", `{"target": {"x": 2, "y": 0}, "execute": false}`, http.StatusOK, rspGoto{rspCmd: rspCmd{rspStatus: rspStatus{Direction: "N", X: 0, Y: 2, Id: "abc"}, Collisions: []int{}}, Plan: "FFRFF"}, ""},
+		{"Execute", `{"target": {"x": 2, "y": 0}}`, http.StatusOK, rspGoto{rspCmd: rspCmd{rspStatus: rspStatus{Direction: "E", X: 2, Y: 0, Id: "abc"}, Collisions: []int{}}, Plan: "FFRFF", Executed: true}, ""},
+		{"Blocked target", `{"target": {"x": 1, "y": 1}}`, http.StatusBadRequest, rspGoto{}, "unreachable"},
+		{"Missing target", `{}`, http.StatusBadRequest, rspGoto{}, "invalid_request"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := serve(t, robotHandler.goTo, "POST", "/robot/abc/goto", "abc", tt.body)
+
+			if rr.Code != tt.code {
+				t.Errorf("wrong status code: got %v want %v", rr.Code, tt.code)
+			}
+
+			if tt.errCode != "" {
+				if !hasErrorCode(rr.Body.Bytes(), tt.errCode) {
+					t.Errorf("wrong error: got %s want %s", rr.Body.String(), tt.errCode)
+				}
+				return
+			}
+
+			rsp := rspGoto{}
+			json.Unmarshal(rr.Body.Bytes(), &rsp)
+			if !reflect.DeepEqual(rsp, tt.rsp) {
+				t.Errorf("wrong response: got %+v want %+v", rsp, tt.rsp)
+			}
+		})
+	}
+}
+
+func TestRobotHandler_getSweep(t *testing.T) {
+
+	robotStore := storage.NewRobotMemStore()
+	robotHandler := RobotHandler{store: robotStore}
+
+	r, err := robot.NewRobot(robot.Room{X: 3, Y: 3}, "E", robot.Coordinate{X: 0, Y: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	robotStore.Put("abc", r, context.Background())
+
+	rr := serve(t, robotHandler.getSweep, "GET", "/robot/abc/sweep", "abc", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	rsp := rspSweep{}
+	json.Unmarshal(rr.Body.Bytes(), &rsp)
+
+	want := rspSweep{Id: "abc", Plan: "FFRFRFFLFLFF", Length: 12, Turns: 4, Covered: 9}
+	if rsp != want {
+		t.Errorf("wrong response: got %+v want %+v", rsp, want)
+	}
+
+	rr = serve(t, robotHandler.getSweep, "GET", "/robot/abcd/sweep", "abcd", "")
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+	}
+}
+
+func TestRobotHandler_getCoverage(t *testing.T) {
+
+	robotStore := storage.NewRobotMemStore()
+	robotHandler := RobotHandler{store: robotStore}
+
+	r, err := robot.NewRobot(robot.Room{X: 2, Y: 2}, "E", robot.Coordinate{X: 0, Y: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	robotStore.Put("abc", r, context.Background())
+	r.Cmd("FLLF")
+
+	rr := serve(t, robotHandler.getCoverage, "GET", "/robot/abc/coverage", "abc", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	rsp := rspCoverage{}
+	json.Unmarshal(rr.Body.Bytes(), &rsp)
+
+	want := rspCoverage{Id: "abc", Percentage: 50, Free: 4, Visited: 2, Visits: [][]uint32{{2, 1}, {0, 0}}}
+	if !reflect.DeepEqual(rsp, want) {
+		t.Errorf("wrong response: got %+v want %+v", rsp, want)
+	}
+
+	rr = serve(t, robotHandler.getCoverage, "GET", "/robot/abcd/coverage", "abcd", "")
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+	}
+}
+
+func TestRobotHandler_getSensors(t *testing.T) {
+
+	robotStore := storage.NewRobotMemStore()
+	robotHandler := RobotHandler{store: robotStore}
+
+	r, err := robot.NewRobot(robot.Room{X: 4, Y: 3, Obstacles: []robot.Coordinate{{X: 3, Y: 1}}}, "E", robot.Coordinate{X: 0, Y: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	robotStore.Put("abc", r, context.Background())
+
+	rr := serve(t, robotHandler.getSensors, "GET", "/robot/abc/sensors", "abc", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	rsp := rspSensors{}
+	json.Unmarshal(rr.Body.Bytes(), &rsp)
+
+	want := rspSensors{Id: "abc", Front: rspReading{2, "obstacle"}, Left: rspReading{1, "wall"}, Right: rspReading{1, "wall"}}
+	if rsp != want {
+		t.Errorf("wrong response: got %+v want %+v", rsp, want)
+	}
+
+	rr = serve(t, robotHandler.getSensors, "GET", "/robot/abcd/sensors", "abcd", "")
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+	}
+}
+
+func TestRobotHandler_simulate(t *testing.T) {
+
+	robotStore := storage.NewRobotMemStore()
+	robotHandler := RobotHandler{store: robotStore}
+
+	r, err := robot.NewRobot(robot.Room{X: 2, Y: 2}, "N", robot.Coordinate{X: 0, Y: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	robotStore.Put("abc", r, context.Background())
+
+	tests := []struct {
+		name    string
+		body    string
+		code    int
+		rsp     rspCmd
+		errCode string
+	}{
+		{"Simulate", `{"cmd": "FFRFF"}`, http.StatusOK, rspCmd{rspStatus: rspStatus{Direction: "E", X: 1, Y: 0, Id: "abc"}, Bumps: 2, Collisions: []int{1, 4}}, ""},
+		{"Invalid command", `{"cmd": "FFX"}`, http.StatusBadRequest, rspCmd{rspStatus: rspStatus{Direction: "N", X: 0, Y: 1, Id: "abc"}, Collisions: []int{}}, "invalid_command"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := serve(t, robotHandler.simulate, "POST", "/robot/abc/simulate", "abc", tt.body)
+
+			if rr.Code != tt.code {
+				t.Errorf("wrong status code: got %v want %v", rr.Code, tt.code)
+			}
+
+			rsp := rspCmd{}
+			json.Unmarshal(rr.Body.Bytes(), &rsp)
+
+			errCode := ""
+			if rsp.Error != nil {
+				errCode = rsp.Error.Code
+				rsp.Error = nil
+			}
+			if !reflect.DeepEqual(rsp, tt.rsp) || errCode != tt.errCode {
+				t.Errorf("wrong response: got %+v %s want %+v %s", rsp, errCode, tt.rsp, tt.errCode)
+			}
+		})
+	}
+
+	// The robot has not moved.
+	if d, c := r.Report(); d != "N" || c != (robot.Coordinate{X: 0, Y: 1}) {
+		t.Errorf("the robot moved to %s %+v", d, c)
+	}
+}
+
+func TestRobotHandler_runProgram(t *testing.T) {
+
+	robotStore := storage.NewRobotMemStore()
+	robotHandler := RobotHandler{store: robotStore}
+
+	r, err := robot.NewRobot(robot.Room{X: 3, Y: 3}, "N", robot.Coordinate{X: 0, Y: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	robotStore.Put("abc", r, context.Background())
+
+	tests := []struct {
+		name    string
+		body    string
+		code    int
+		rsp     rspProgram
+		errCode string
+	}{
+		{"Run", `{"program": "while !wall_ahead { F } R"}`, http.StatusOK, rspProgram{rspCmd{rspStatus: rspStatus{Direction: "E", X: 0, Y: 0, Id: "abc"}, Collisions: []int{}}, 3}, ""},
+		{"Step limit", `{"program": "while wall_left { }", "limit": 400}`, http.StatusBadRequest, rspProgram{rspCmd{rspStatus: rspStatus{Direction: "E", X: 0, Y: 0, Id: "abc"}, Collisions: []int{}}, 0}, "step_limit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := serve(t, robotHandler.runProgram, "POST", "/robot/abc/program", "abc", tt.body)
+
+			if rr.Code != tt.code {
+				t.Errorf("wrong status code: got %v want %v", rr.Code, tt.code)
+			}
+
+			rsp := rspProgram{}
+			json.Unmarshal(rr.Body.Bytes(), &rsp)
+
+			errCode := ""
+			if rsp.Error != nil {
+				errCode = rsp.Error.Code
+				rsp.Error = nil
+			}
+			if !reflect.DeepEqual(rsp, tt.rsp) || errCode != tt.errCode {
+				t.Errorf("wrong response: got %+v %s want %+v %s", rsp, errCode, tt.rsp, tt.errCode)
+			}
+		})
+	}
+
+	errTests := []struct {
+		name string
+		id   string
+		body string
+		code int
+		err  string
+	}{
+		{"Syntax error", "abc", `{"program": "while { F }"}`, http.StatusBadRequest, "syntax_error"},
+		{"Zero limit", "abc", `{"program": "F", "limit": 0}`, http.StatusBadRequest, "invalid_limit"},
+		{"Too large limit", "abc", `{"program": "F", "limit": 1000001}`, http.StatusBadRequest, "invalid_limit"},
+		{"Unknown robot", "abcd", `{"program": "F"}`, http.StatusNotFound, "robot_not_found"},
+	}
+
+	for _, tt := range errTests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := serve(t, robotHandler.runProgram, "POST", "/robot/"+tt.id+"/program", tt.id, tt.body)
+
+			if rr.Code != tt.code || !hasErrorCode(rr.Body.Bytes(), tt.err) {
+				t.Errorf("wrong response: got %v %s want %v %s", rr.Code, rr.Body.String(), tt.code, tt.err)
+			}
+		})
+	}
+}
+
+func TestRobotHandler_optimize(t *testing.T) {
+
+	robotStore := storage.NewRobotMemStore()
+	robotHandler := RobotHandler{store: robotStore}
+
+	r, err := robot.NewRobot(robot.Room{X: 3, Y: 3}, "N", robot.Coordinate{X: 0, Y: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	robotStore.Put("abc", r, context.Background())
+
+	tests := []struct {
+		name string
+		body string
+		code int
+		rsp  rspOptimize
+		err  string
+	}{
+		{"Four-point", `{"cmd": "LLLLFLRRRRF"}`, http.StatusOK, rspOptimize{Cmd: "LLLLFLRRRRF", Optimized: "FLF", Steps: 11, OptimizedSteps: 3, Savings: 8}, ""},
+		{"Eight-point", `{"cmd": "LLLLFLR", "compass": 8}`, http.StatusOK, rspOptimize{Cmd: "LLLLFLR", Optimized: "4LF", Steps: 7, OptimizedSteps: 5, Savings: 2}, ""},
+		{"Robot", `{"cmd": "3FLF", "robot_id": "abc"}`, http.StatusOK, rspOptimize{Cmd: "3FLF", Optimized: "FFL", Steps: 5, OptimizedSteps: 3, Savings: 2}, ""},
+		{"Syntax error", `{"cmd": "3[F"}`, http.StatusBadRequest, rspOptimize{}, "syntax_error"},
+		{"Invalid compass", `{"cmd": "F", "compass": 6}`, http.StatusBadRequest, rspOptimize{}, "invalid_compass"},
+		{"Compass and robot", `{"cmd": "F", "compass": 4, "robot_id": "abc"}`, http.StatusBadRequest, rspOptimize{}, "invalid_request"},
+		{"Unknown robot", `{"cmd": "F", "robot_id": "abcd"}`, http.StatusNotFound, rspOptimize{}, "robot_not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := serve(t, robotHandler.optimize, "POST", "/commands/optimize", "", tt.body)
+
+			if rr.Code != tt.code {
+				t.Errorf("wrong status code: got %v want %v", rr.Code, tt.code)
+			}
+
+			if tt.err != "" {
+				if !hasErrorCode(rr.Body.Bytes(), tt.err) {
+					t.Errorf("wrong error: got %s want %s", rr.Body.String(), tt.err)
+				}
+				return
+			}
+
+			rsp := rspOptimize{}
+			json.Unmarshal(rr.Body.Bytes(), &rsp)
+			if rsp != tt.rsp {
+				t.Errorf("wrong response: got %+v want %+v", rsp, tt.rsp)
+			}
+		})
+	}
+}
+
+func TestRobotHandler_charge(t *testing.T) {
+
+	robotHandler := RobotHandler{store: storage.NewRobotMemStore()}
+
+	rr := serve(t, robotHandler.create, "POST", "/robot", "", `{"direction": "N", "room": {"x": 3, "y": 3}, "start": {"x": 1, "y": 2}, "battery": {"capacity": 3, "costs": {"f": 2, "l": 1, "r": 1}}}`)
+	status := rspStatus{}
+	json.Unmarshal(rr.Body.Bytes(), &status)
+	if rr.Code != http.StatusOK || status.Energy == nil || *status.Energy != 3 {
+		t.Fatalf("wrong response: got %v %s", rr.Code, rr.Body.String())
+	}
+
+	rr = serve(t, robotHandler.command, "POST", "/robot/"+status.Id, status.Id, `{"cmd": "FF"}`)
+	if rr.Code != http.StatusBadRequest || !hasErrorCode(rr.Body.Bytes(), "out_of_energy") {
+		t.Errorf("wrong response: got %v %s", rr.Code, rr.Body.String())
+	}
+
+	rr = serve(t, robotHandler.charge, "POST", "/robot/"+status.Id+"/charge", status.Id, "")
+	status = rspStatus{}
+	json.Unmarshal(rr.Body.Bytes(), &status)
+	if rr.Code != http.StatusOK || status.Energy == nil || *status.Energy != 3 || status.Y != 1 {
+		t.Errorf("wrong response: got %v %s", rr.Code, rr.Body.String())
+	}
+
+	// A robot without a battery can not be charged.
+	rr = serve(t, robotHandler.create, "POST", "/robot", "", `{"direction": "N", "room": {"x": 3, "y": 3}}`)
+	json.Unmarshal(rr.Body.Bytes(), &status)
+	rr = serve(t, robotHandler.charge, "POST", "/robot/"+status.Id+"/charge", status.Id, "")
+	if rr.Code != http.StatusConflict || !hasErrorCode(rr.Body.Bytes(), "no_battery") {
+		t.Errorf("wrong response: got %v %s", rr.Code, rr.Body.String())
+	}
+
+	rr = serve(t, robotHandler.charge, "POST", "/robot/abcd/charge", "abcd", "")
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+	}
+}
+
+func TestRobotHandler_commandTimed(t *testing.T) {
+
+	robotHandler := RobotHandler{store: storage.NewRobotMemStore()}
+
+	rr := serve(t, robotHandler.create, "POST", "/robot", "", `{"direction": "N", "room": {"x": 5, "y": 5}, "start": {"x": 0, "y": 4}, "timing": {"move_ms": 20, "turn_ms": 10}}`)
+	status := rspStatus{}
+	json.Unmarshal(rr.Body.Bytes(), &status)
+	id := status.Id
+
+	rr = serve(t, robotHandler.command, "POST", "/robot/"+id, id, `{"cmd": "3F"}`)
+	job := rspJob{}
+	json.Unmarshal(rr.Body.Bytes(), &job)
+	if rr.Code != http.StatusAccepted || job != (rspJob{Id: id, JobId: 1, Cmd: "3F", Steps: 3}) {
+		t.Errorf("wrong response: got %v %s", rr.Code, rr.Body.String())
+	}
+
+	// A route can not be planned while the robot has queued jobs, since they move the robot first.
+	rr = serve(t, robotHandler.goTo, "POST", "/robot/"+id+"/goto", id, `{"target": {"x": 2, "y": 1}}`)
+	if rr.Code != http.StatusConflict || !hasErrorCode(rr.Body.Bytes(), "busy") {
+		t.Errorf("wrong response: got %v %s", rr.Code, rr.Body.String())
+	}
+
+	rr = serve(t, robotHandler.command, "POST", "/robot/"+id+"?trace=true", id, `{"cmd": "F"}`)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+	}
+
+	// The queue is shown until the robot has executed all commands.
+	got := rspRobot{}
+	rr = serve(t, robotHandler.getStatus, "GET", "/robot/"+id, id, "")
+	json.Unmarshal(rr.Body.Bytes(), &got)
+	if len(got.Queue) != 1 || got.Queue[0].JobId != 1 {
+		t.Errorf("wrong queue: got %s", rr.Body.String())
+	}
+
+	wait := func() {
+		deadline := time.Now().Add(5 * time.Second)
+		for len(got.Queue) > 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+			got = rspRobot{}
+			rr = serve(t, robotHandler.getStatus, "GET", "/robot/"+id, id, "")
+			json.Unmarshal(rr.Body.Bytes(), &got)
+		}
+	}
+	wait()
+
+	rr = serve(t, robotHandler.goTo, "POST", "/robot/"+id+"/goto", id, `{"target": {"x": 2, "y": 1}}`)
+	rsp := rspGoto{}
+	json.Unmarshal(rr.Body.Bytes(), &rsp)
+	if rr.Code != http.StatusAccepted || rsp.Job == nil || rsp.Job.JobId != 2 || rsp.Plan != "RFF" || rsp.Executed {
+		t.Errorf("wrong response: got %v %s", rr.Code, rr.Body.String())
+	}
+
+	got = rspRobot{Queue: []rspJob{*rsp.Job}}
+	wait()
+
+	// The plan was made from where the robot was when it was queued.
+	if got.Queue != nil || got.rspStatus != (rspStatus{Direction: "E", X: 2, Y: 1, Id: id}) {
+		t.Errorf("wrong status: got %s", rr.Body.String())
+	}
+	if len(got.Finished) != 2 || got.Finished[1].End == nil || *got.Finished[1].End != (rspPose{Direction: "E", X: 2, Y: 1}) || got.Finished[1].Error != nil {
+		t.Errorf("wrong finished jobs: got %s", rr.Body.String())
+	}
+}
+
+func TestRobotHandler_commandTimedError(t *testing.T) {
+
+	robotHandler := RobotHandler{store: storage.NewRobotMemStore()}
+
+	rr := serve(t, robotHandler.create, "POST", "/robot", "", `{"direction": "N", "room": {"x": 5, "y": 5}, "collision": "stop", "timing": {"move_ms": 1, "turn_ms": 1}}`)
+	status := rspStatus{}
+	json.Unmarshal(rr.Body.Bytes(), &status)
+	id := status.Id
+
+	if rr = serve(t, robotHandler.command, "POST", "/robot/"+id, id, `{"cmd": "FRF"}`); rr.Code != http.StatusAccepted {
+		t.Fatalf("wrong status code: got %v %s", rr.Code, rr.Body.String())
+	}
+
+	got := rspRobot{}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(got.Finished) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+		got = rspRobot{}
+		rr = serve(t, robotHandler.getStatus, "GET", "/robot/"+id, id, "")
+		json.Unmarshal(rr.Body.Bytes(), &got)
+	}
+
+	// The client can find out why the job it queued was aborted.
+	if len(got.Finished) != 1 {
+		t.Fatalf("wrong finished jobs: got %s", rr.Body.String())
+	}
+	job := got.Finished[0]
+	if job.Done != 1 || job.End == nil || *job.End != (rspPose{Direction: "N", X: 0, Y: 0}) || job.Error == nil || job.Error.Code != "collision" || *job.Error.Step != 0 {
+		t.Errorf("wrong finished job: got %s", rr.Body.String())
+	}
+}
+
+func TestRobotHandler_undoRedo(t *testing.T) {
+
+	robotStore := storage.NewRobotMemStore()
+	robotHandler := RobotHandler{store: robotStore}
+
+	r, err := robot.NewRobot(robot.Room{X: 3, Y: 3}, "N", robot.Coordinate{X: 1, Y: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	robotStore.Put("abc", r, context.Background())
+	r.Cmd("FRF")
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		id      string
+		code    int
+		status  rspStatus
+	}{
+		{"Undo", robotHandler.undo, "abc", http.StatusOK, rspStatus{Direction: "N", X: 1, Y: 2, Id: "abc"}},
+		{"Undo with nothing to undo", robotHandler.undo, "abc", http.StatusConflict, rspStatus{}},
+		{"Redo", robotHandler.redo, "abc", http.StatusOK, rspStatus{Direction: "E", X: 2, Y: 1, Id: "abc"}},
+		{"Redo with nothing to redo", robotHandler.redo, "abc", http.StatusConflict, rspStatus{}},
+		{"Undo a robot that is not in the store", robotHandler.undo, "abcd", http.StatusNotFound, rspStatus{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := serve(t, tt.handler, "POST", "/robot/"+tt.id+"/undo", tt.id, "")
+
+			if rr.Code != tt.code {
+				t.Errorf("wrong status code: got %v want %v", rr.Code, tt.code)
+			}
+
+			rsp := rspStatus{}
+			json.Unmarshal(rr.Body.Bytes(), &rsp)
+			if rsp != tt.status {
+				t.Errorf("wrong status: got %+v want %+v", rsp, tt.status)
+			}
+		})
+	}
+}
+
+func TestRobotHandler_getEvents(t *testing.T) {
+
+	robotHandler := RobotHandler{store: storage.NewRobotMemStore(), events: storage.NewEventMemLog()}
+
+	rr := serve(t, robotHandler.create, "POST", "/robot", "", `{"direction": "N", "room": {"x": 3, "y": 3}, "start": {"x": 1, "y": 1}}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	status := rspStatus{}
+	json.Unmarshal(rr.Body.Bytes(), &status)
+
+	serve(t, robotHandler.command, "POST", "/robot/"+status.Id, status.Id, `{"cmd": "RF"}`)
+	// Rejected command strings are not recorded.
+	serve(t, robotHandler.command, "POST", "/robot/"+status.Id, status.Id, `{"cmd": "X"}`)
+	serve(t, robotHandler.undo, "POST", "/robot/"+status.Id+"/undo", status.Id, "")
+
+	rr = serve(t, robotHandler.getEvents, "GET", "/robot/"+status.Id+"/events", status.Id, "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	rsp := rspEvents{}
+	json.Unmarshal(rr.Body.Bytes(), &rsp)
+
+	want := []robot.Event{
+		{Kind: robot.EventCreate, Direction: "N", Coordinate: robot.Coordinate{X: 1, Y: 1}},
+		{Kind: robot.EventCmd, Cmd: "RF", Direction: "E", Coordinate: robot.Coordinate{X: 2, Y: 1}},
+		{Kind: robot.EventUndo, Direction: "N", Coordinate: robot.Coordinate{X: 1, Y: 1}},
+	}
+
+	if rsp.Id != status.Id || len(rsp.Events) != len(want) {
+		t.Fatalf("wrong events: got %+v want %+v", rsp, want)
+	}
+	for i, e := range rsp.Events {
+		e.Time = time.Time{}
+		if e != want[i] {
+			t.Errorf("wrong event %d: got %+v want %+v", i, e, want[i])
+		}
+	}
+
+	rr = serve(t, robotHandler.getEvents, "GET", "/robot/abcd/events", "abcd", "")
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+	}
+}
+
 // This can be used together with pprof as a quick an dirty way to find any general perf issues.
 func BenchmarkRobotHandler_create(b *testing.B) {
 
@@ -32,6 +32,11 @@ func (rww *RWWrapper) WriteHeader(statusCode int) {
 	rww.responseWriter.WriteHeader(statusCode)
 }
 
+// Returns the wrapped response writer, so http.ResponseController can flush streamed responses.
+func (rww *RWWrapper) Unwrap() http.ResponseWriter {
+	return rww.responseWriter
+}
+
 func Chain(handler http.Handler, middlewares ...Middleware) http.Handler {
 	for _, m := range middlewares {
 		handler = m(handler)
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/anfly0/cuddly-octo-bassoon/internal/robot"
+	"github.com/anfly0/cuddly-octo-bassoon/internal/storage"
+	"github.com/anfly0/cuddly-octo-bassoon/internal/utils"
+)
+
+type rspRoom struct {
+	robot.Room
+	Id     string `json:"id"`
+	Robots int    `json:"robots"`
+}
+
+func RspRoomFromSharedRoom(sr *robot.SharedRoom, id string) rspRoom {
+	return rspRoom{Room: sr.Room(), Id: id, Robots: sr.Len()}
+}
+
+type RoomHandler struct {
+	store  storage.RoomStore
+	robots storage.RobotStore
+}
+
+func (rh *RoomHandler) create(w http.ResponseWriter, r *http.Request) {
+
+	req := robot.Room{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, decodeError(err))
+		return
+	}
+
+	if errs := validateRoom("", req); len(errs) > 0 {
+		writeError(w, http.StatusBadRequest, errs)
+		return
+	}
+
+	sr, err := robot.NewSharedRoom(req)
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	id, err := utils.RandId(4)
+
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, errInternal)
+		return
+	}
+
+	rh.store.Put(id, sr, r.Context())
+
+	rsp := RspRoomFromSharedRoom(sr, id)
+
+	j, _ := json.Marshal(rsp)
+	io.WriteString(w, string(j))
+}
+
+func (rh *RoomHandler) get(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	sr := rh.store.Get(id, r.Context())
+
+	if sr == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%w: %s", storage.ErrRoomNotFound, id))
+		return
+	}
+
+	rsp := RspRoomFromSharedRoom(sr, id)
+
+	j, _ := json.Marshal(rsp)
+	io.WriteString(w, string(j))
+}
+
+func (rh *RoomHandler) getRobots(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	if rh.store.Get(id, r.Context()) == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%w: %s", storage.ErrRoomNotFound, id))
+		return
+	}
+
+	rsp := []rspStatus{}
+	for _, rid := range rh.store.Robots(id, r.Context()) {
+		if rb := rh.robots.Get(rid, r.Context()); rb != nil {
+			rsp = append(rsp, RspStatusFromRobot(rb, rid))
+		}
+	}
+
+	j, _ := json.Marshal(rsp)
+	io.WriteString(w, string(j))
+}
+
+func (rh *RoomHandler) delete(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	err := rh.store.Delete(id, r.Context())
+
+	if errors.Is(err, storage.ErrRoomNotFound) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%w: %s", storage.ErrRoomNotFound, id))
+		return
+	}
+
+	if err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
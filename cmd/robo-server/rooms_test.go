@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/anfly0/cuddly-octo-bassoon/internal/storage"
+)
+
+// Sends a request to the handler and returns the response recorder.
+func serve(t *testing.T, h http.HandlerFunc, method string, path string, id string, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req, err := http.NewRequest(method, path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "" {
+		req.SetPathValue("id", id)
+	}
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestRoomHandler(t *testing.T) {
+
+	robots := storage.NewRobotMemStore()
+	rooms := storage.NewRoomMemStore()
+	robotHandler := RobotHandler{store: robots, rooms: rooms}
+	roomHandler := RoomHandler{store: rooms, robots: robots}
+
+	// Create a room.
+	rr := serve(t, roomHandler.create, "POST", "/room", "", `{"x": 3, "y": 3, "obstacles": [{"x": 1, "y": 1}]}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	room := rspRoom{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &room); err != nil {
+		t.Fatal(err)
+	}
+	if room.Id == "" || room.X != 3 || room.Y != 3 || len(room.Obstacles) != 1 || room.Robots != 0 {
+		t.Fatalf("unexpected room %+v", room)
+	}
+
+	for _, body := range []string{`{"map": ["..", "."]}`, `{"x": 0, "y": 3}`, `{"x": 3, "y": 3000}`} {
+		if rr := serve(t, roomHandler.create, "POST", "/room", "", body); rr.Code != http.StatusBadRequest {
+			t.Errorf("wrong status code for invalid room %s: got %v want %v", body, rr.Code, http.StatusBadRequest)
+		}
+	}
+
+	// Create two robots in the room, the second one can not start in the cell of the first one.
+	ids := []string{}
+	for i, tt := range []struct {
+		body string
+		code int
+	}{
+		{fmt.Sprintf(`{"direction": "E", "room_id": "%s", "start": {"x": 0, "y": 0}}`, room.Id), http.StatusOK},
+		{fmt.Sprintf(`{"direction": "W", "room_id": "%s", "start": {"x": 0, "y": 0}}`, room.Id), http.StatusBadRequest},
+		{fmt.Sprintf(`{"direction": "W", "room_id": "%s", "start": {"x": 2, "y": 0}}`, room.Id), http.StatusOK},
+		{fmt.Sprintf(`{"direction": "W", "room_id": "%s", "room": {"x": 3, "y": 3}, "start": {"x": 2, "y": 2}}`, room.Id), http.StatusBadRequest},
+		{`{"direction": "W", "room_id": "nope", "start": {"x": 2, "y": 2}}`, http.StatusBadRequest},
+	} {
+		rr := serve(t, robotHandler.create, "POST", "/robot", "", tt.body)
+		if rr.Code != tt.code {
+			t.Fatalf("robot %d: wrong status code: got %v want %v", i, rr.Code, tt.code)
+		}
+
+		if rr.Code == http.StatusOK {
+			rsp := rspStatus{}
+			json.Unmarshal(rr.Body.Bytes(), &rsp)
+			ids = append(ids, rsp.Id)
+		}
+	}
+
+	// The robots collide with each other.
+	rr = serve(t, robotHandler.command, "POST", "/robot/"+ids[0], ids[0], `{"cmd": "FF"}`)
+	cmd := rspCmd{}
+	json.Unmarshal(rr.Body.Bytes(), &cmd)
+	if cmd.X != 1 || cmd.Bumps != 1 {
+		t.Errorf("unexpected command response %+v", cmd)
+	}
+
+	rr = serve(t, roomHandler.get, "GET", "/room/"+room.Id, room.Id, "")
+	json.Unmarshal(rr.Body.Bytes(), &room)
+	if rr.Code != http.StatusOK || room.Robots != 2 {
+		t.Errorf("wrong room status %v %+v", rr.Code, room)
+	}
+
+	rr = serve(t, roomHandler.getRobots, "GET", "/room/"+room.Id+"/robots", room.Id, "")
+	statuses := []rspStatus{}
+	json.Unmarshal(rr.Body.Bytes(), &statuses)
+	if rr.Code != http.StatusOK || len(statuses) != 2 || statuses[0].Id != ids[0] || statuses[1].Id != ids[1] {
+		t.Errorf("wrong robots in room %v %+v", rr.Code, statuses)
+	}
+
+	// Rooms with robots in them can not be deleted.
+	if rr := serve(t, roomHandler.delete, "DELETE", "/room/"+room.Id, room.Id, ""); rr.Code != http.StatusConflict {
+		t.Errorf("wrong status code: got %v want %v", rr.Code, http.StatusConflict)
+	}
+
+	// Empty rooms can.
+	rr = serve(t, roomHandler.create, "POST", "/room", "", `{"x": 1, "y": 1}`)
+	empty := rspRoom{}
+	json.Unmarshal(rr.Body.Bytes(), &empty)
+	if rr := serve(t, roomHandler.delete, "DELETE", "/room/"+empty.Id, empty.Id, ""); rr.Code != http.StatusNoContent {
+		t.Errorf("wrong status code: got %v want %v", rr.Code, http.StatusNoContent)
+	}
+
+	for _, h := range []http.HandlerFunc{roomHandler.get, roomHandler.getRobots, roomHandler.delete} {
+		if rr := serve(t, h, "GET", "/room/"+empty.Id, empty.Id, ""); rr.Code != http.StatusNotFound {
+			t.Errorf("wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/anfly0/cuddly-octo-bassoon/internal/robot"
+)
+
+// The largest room, in cells along any side, that can be created through the API.
+const maxRoomSide = 1000
+
+var errRoomTooLarge = fmt.Errorf("the room must not be larger than %d cells along any side", maxRoomSide)
+
+// Errors for the obstacles and chargers of a room.
+var (
+	errCellOutsideRoom = errors.New("the cell is outside the room")
+	errCellBlocked     = errors.New("the cell is blocked")
+)
+
+var errInvalidBattery = errors.New("the battery capacity must be at least 1")
+
+// The longest time a single command can take for robots with timing. Queued jobs can not be canceled, so this bounds how long a robot is busy.
+const maxCommandMs = 1000
+
+var errInvalidTiming = fmt.Errorf("the time of a command must be at most %d ms", maxCommandMs)
+
+// The step limit of a program request if none is given and the largest limit that can be given.
+const (
+	defaultStepLimit = 10000
+	maxStepLimit     = 1000000
+)
+
+var errInvalidLimit = fmt.Errorf("the step limit must be between 1 and %d", maxStepLimit)
+
+// The number of steps between the progress lines of a streamed command string if the interval is not given.
+const defaultProgressInterval = 10000
+
+// fieldError is a validation error for a single field of a request. Nested fields are separated with a dot, e.g. room.x.
+type fieldError struct {
+	Field string
+	Err   error
+}
+
+// validationError contains all field errors of a request, so the client can fix them all at once.
+type validationError []fieldError
+
+func (ve validationError) Error() string {
+	msgs := make([]string, len(ve))
+	for i, fe := range ve {
+		msgs[i] = fmt.Sprintf("%s: %v", fe.Field, fe.Err)
+	}
+	return "the request is not valid: " + strings.Join(msgs, "; ")
+}
+
+// A create request that has passed validation.
+type validCreate struct {
+	direction string
+	mode      robot.CompassMode
+	policy    robot.CollisionPolicy
+}
+
+// Validates all fields of a create request. If any field is invalid the returned error is a validationError.
+func validateCreate(req reqCreate) (validCreate, error) {
+	v := validCreate{}
+	var errs validationError
+
+	switch req.Compass {
+	case 0, 4:
+		v.mode = robot.FourPoint
+	case 8:
+		v.mode = robot.EightPoint
+	default:
+		errs = append(errs, fieldError{"compass", errInvalidCompass})
+	}
+
+	d, err := robot.ParseDirection(req.Direction, v.mode)
+	if err != nil {
+		errs = append(errs, fieldError{"direction", err})
+	}
+	v.direction = d
+
+	if v.policy, err = robot.ParseCollisionPolicy(req.Collision); err != nil {
+		errs = append(errs, fieldError{"collision", err})
+	}
+
+	if req.Battery != nil && req.Battery.Capacity == 0 {
+		errs = append(errs, fieldError{"battery.capacity", errInvalidBattery})
+	}
+
+	if req.Timing != nil {
+		if req.Timing.MoveMs > maxCommandMs {
+			errs = append(errs, fieldError{"timing.move_ms", errInvalidTiming})
+		}
+		if req.Timing.TurnMs > maxCommandMs {
+			errs = append(errs, fieldError{"timing.turn_ms", errInvalidTiming})
+		}
+	}
+
+	if req.RoomId != "" {
+		if req.Room.X != 0 || req.Room.Y != 0 || req.Room.Obstacles != nil || req.Room.Chargers != nil || req.Room.Map != nil {
+			errs = append(errs, fieldError{"room", fmt.Errorf("%w: a robot can not have both a room and a room id", errInvalidRequest)})
+		}
+	} else if roomErrs := validateRoom("room.", req.Room); len(roomErrs) > 0 {
+		errs = append(errs, roomErrs...)
+	} else if x, y := roomSize(req.Room); req.Start.X >= x || req.Start.Y >= y {
+		errs = append(errs, fieldError{"start", robot.ErrOutsideRoom})
+	}
+
+	if len(errs) > 0 {
+		return v, errs
+	}
+	return v, nil
+}
+
+// Validates the dimensions of a room and that its obstacles and chargers are inside it. The prefix is prepended to the names of the fields.
+func validateRoom(prefix string, r robot.Room) validationError {
+	var errs validationError
+
+	if r.Map != nil {
+		x, y := r.Map.Size()
+		if (r.X != 0 && r.X != x) || (r.Y != 0 && r.Y != y) {
+			errs = append(errs, fieldError{prefix + "map", robot.ErrMapMismatch})
+		}
+		if x > maxRoomSide || y > maxRoomSide {
+			errs = append(errs, fieldError{prefix + "map", errRoomTooLarge})
+		}
+	} else {
+		for _, side := range []struct {
+			field string
+			size  uint
+		}{{"x", r.X}, {"y", r.Y}} {
+			switch {
+			case side.size == 0:
+				errs = append(errs, fieldError{prefix + side.field, robot.ErrEmptyRoom})
+			case side.size > maxRoomSide:
+				errs = append(errs, fieldError{prefix + side.field, errRoomTooLarge})
+			}
+		}
+	}
+
+	// The cells can only be checked against a room of a valid size.
+	if len(errs) > 0 {
+		return errs
+	}
+
+	x, y := roomSize(r)
+	obstacles := make(map[robot.Coordinate]bool, len(r.Obstacles))
+	for i, o := range r.Obstacles {
+		obstacles[o] = true
+		if o.X >= x || o.Y >= y {
+			errs = append(errs, fieldError{fmt.Sprintf("%sobstacles[%d]", prefix, i), errCellOutsideRoom})
+		}
+	}
+
+	for i, c := range r.Chargers {
+		field := fmt.Sprintf("%schargers[%d]", prefix, i)
+		switch {
+		case c.X >= x || c.Y >= y:
+			errs = append(errs, fieldError{field, errCellOutsideRoom})
+		case obstacles[c] || (r.Map != nil && r.Map.Blocked(c)):
+			errs = append(errs, fieldError{field, errCellBlocked})
+		}
+	}
+
+	return errs
+}
+
+// Returns the size of a room that has passed validation.
+func roomSize(r robot.Room) (uint, uint) {
+	if r.Map != nil {
+		return r.Map.Size()
+	}
+	return r.X, r.Y
+}
+
+// Returns the field errors of err if it is a validationError.
+func fieldErrors(err error) (validationError, bool) {
+	var ve validationError
+	ok := errors.As(err, &ve)
+	return ve, ok
+}
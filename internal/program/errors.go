@@ -0,0 +1,29 @@
+package program
+
+import (
+	"fmt"
+)
+
+// Errors returned by the program package. Use errors.Is to check for them.
+var (
+	ErrTooDeep = fmt.Errorf("procedure calls can not be nested deeper than %d", maxDepth)
+)
+
+// ErrSyntax is returned when a program is not valid according to the grammar. Index is the position in the source where the problem was found.
+type ErrSyntax struct {
+	Index int
+	Msg   string
+}
+
+func (e ErrSyntax) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Index)
+}
+
+// ErrStepLimit is returned when a program does not finish within the step limit. Limit is the step limit.
+type ErrStepLimit struct {
+	Limit int
+}
+
+func (e ErrStepLimit) Error() string {
+	return fmt.Sprintf("the program did not finish within %d steps", e.Limit)
+}
@@ -0,0 +1,277 @@
+package program
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// All valid commands, the same as in a command string.
+const commands = "LRFB<>"
+
+var keywords = map[string]bool{"proc": true, "while": true, "if": true, "else": true, "repeat": true}
+
+// token is a word, a number or one of the runes "{", "}" and "!". The text of the end of the source is empty.
+type token struct {
+	text string
+	// Position of the first rune of the token in the source.
+	pos int
+}
+
+// Splits the source into tokens, skipping white space and comments. The last token is always the end of the source.
+func lex(src string) ([]token, error) {
+	rs := []rune(src)
+	var toks []token
+
+	for i := 0; i < len(rs); {
+		c := rs[i]
+		start := i
+
+		switch {
+		case unicode.IsSpace(c):
+			i++
+			continue
+		case c == '#':
+			for i < len(rs) && rs[i] != '\n' {
+				i++
+			}
+			continue
+		case c == '{' || c == '}' || c == '!':
+			i++
+		case c == '<' || c == '>':
+			for i < len(rs) && (rs[i] == '<' || rs[i] == '>') {
+				i++
+			}
+		case unicode.IsDigit(c):
+			for i < len(rs) && unicode.IsDigit(rs[i]) {
+				i++
+			}
+		case unicode.IsLetter(c) || c == '_':
+			for i < len(rs) && (unicode.IsLetter(rs[i]) || unicode.IsDigit(rs[i]) || rs[i] == '_') {
+				i++
+			}
+		default:
+			return nil, ErrSyntax{Index: i, Msg: fmt.Sprintf("unexpected %q", c)}
+		}
+
+		toks = append(toks, token{text: string(rs[start:i]), pos: start})
+	}
+
+	return append(toks, token{pos: len(rs)}), nil
+}
+
+type parser struct {
+	toks []token
+	i    int
+}
+
+// Parse parses a program. A program that calls a procedure that is not defined is not valid.
+func Parse(src string) (*Program, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{toks: toks}
+	prog := &Program{procs: make(map[string][]statement)}
+
+	for p.peek().text != "" {
+		if p.peek().text != "proc" {
+			st, err := p.statement()
+			if err != nil {
+				return nil, err
+			}
+			prog.main = append(prog.main, st)
+			continue
+		}
+
+		p.next()
+		name := p.next()
+		if !isName(name.text) {
+			return nil, ErrSyntax{Index: name.pos, Msg: "expected a procedure name"}
+		}
+		if _, ok := prog.procs[name.text]; ok {
+			return nil, ErrSyntax{Index: name.pos, Msg: fmt.Sprintf("procedure %q is already defined", name.text)}
+		}
+
+		body, err := p.block()
+		if err != nil {
+			return nil, err
+		}
+		prog.procs[name.text] = body
+	}
+
+	if err := prog.resolve(prog.main); err != nil {
+		return nil, err
+	}
+	for _, body := range prog.procs {
+		if err := prog.resolve(body); err != nil {
+			return nil, err
+		}
+	}
+
+	return prog, nil
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.i]
+}
+
+// Returns the next token. The end of the source is returned over and over once it is reached.
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if p.i < len(p.toks)-1 {
+		p.i++
+	}
+	return t
+}
+
+// Parses a block, including the braces.
+func (p *parser) block() ([]statement, error) {
+	if t := p.next(); t.text != "{" {
+		return nil, ErrSyntax{Index: t.pos, Msg: "expected '{'"}
+	}
+
+	var body []statement
+	for {
+		switch t := p.peek(); t.text {
+		case "}":
+			p.next()
+			return body, nil
+		case "":
+			return nil, ErrSyntax{Index: t.pos, Msg: "missing '}'"}
+		}
+
+		st, err := p.statement()
+		if err != nil {
+			return nil, err
+		}
+		body = append(body, st)
+	}
+}
+
+// Parses a single statement.
+func (p *parser) statement() (statement, error) {
+	t := p.next()
+	st := statement{pos: t.pos}
+	var err error
+
+	switch {
+	case t.text == "while":
+		st.kind = while
+		if st.cond, err = p.condition(); err != nil {
+			return st, err
+		}
+		st.body, err = p.block()
+	case t.text == "if":
+		st.kind = cond
+		if st.cond, err = p.condition(); err != nil {
+			return st, err
+		}
+		if st.body, err = p.block(); err != nil {
+			return st, err
+		}
+		if p.peek().text == "else" {
+			p.next()
+			st.alt, err = p.block()
+		}
+	case t.text == "repeat":
+		st.kind = repeat
+		if st.n, err = p.count(); err != nil {
+			return st, err
+		}
+		st.body, err = p.block()
+	case t.text == "proc":
+		err = ErrSyntax{Index: t.pos, Msg: "procedures can only be defined at the top level"}
+	case isCommands(t.text):
+		st.kind, st.text = cmds, strings.ToUpper(t.text)
+	case isName(t.text):
+		st.kind, st.text = call, t.text
+	case t.text == "":
+		err = ErrSyntax{Index: t.pos, Msg: "unexpected end of program"}
+	default:
+		err = ErrSyntax{Index: t.pos, Msg: fmt.Sprintf("unexpected %q", t.text)}
+	}
+
+	return st, err
+}
+
+// Parses a condition, optionally negated with "!".
+func (p *parser) condition() (condition, error) {
+	c := condition{}
+
+	t := p.next()
+	if t.text == "!" {
+		c.not = true
+		t = p.next()
+	}
+
+	s, ok := sensorNames[t.text]
+	if !ok {
+		return c, ErrSyntax{Index: t.pos, Msg: "expected wall_ahead, wall_left or wall_right"}
+	}
+	c.sensor = s
+
+	return c, nil
+}
+
+// Parses the count of a repeat statement.
+func (p *parser) count() (uint, error) {
+	t := p.next()
+
+	n, err := strconv.ParseUint(t.text, 10, 64)
+	if err != nil || n > math.MaxUint32 {
+		return 0, ErrSyntax{Index: t.pos, Msg: "expected a count between 0 and 4294967295"}
+	}
+
+	return uint(n), nil
+}
+
+// Checks that every procedure that is called in the statements is defined.
+func (prog *Program) resolve(body []statement) error {
+	for _, st := range body {
+		if st.kind == call {
+			if _, ok := prog.procs[st.text]; !ok {
+				return ErrSyntax{Index: st.pos, Msg: fmt.Sprintf("procedure %q is not defined", st.text)}
+			}
+		}
+		if err := prog.resolve(st.body); err != nil {
+			return err
+		}
+		if err := prog.resolve(st.alt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Returns true if the word only consists of commands.
+func isCommands(w string) bool {
+	if w == "" {
+		return false
+	}
+
+	for _, c := range w {
+		if !strings.ContainsRune(commands, unicode.ToUpper(c)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Returns true if the word can be used as a procedure name.
+func isName(w string) bool {
+	if w == "" || keywords[w] || isCommands(w) {
+		return false
+	}
+	if _, ok := sensorNames[w]; ok {
+		return false
+	}
+
+	c := []rune(w)[0]
+	return unicode.IsLetter(c) || c == '_'
+}
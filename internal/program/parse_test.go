@@ -0,0 +1,74 @@
+package program
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		src  string
+		want *Program
+	}{
+		{"", &Program{procs: map[string][]statement{}}},
+		{"ff<r # comment\n", &Program{main: []statement{{kind: cmds, text: "FF", pos: 0}, {kind: cmds, text: "<", pos: 2}, {kind: cmds, text: "R", pos: 3}}, procs: map[string][]statement{}}},
+		{
+			"while !wall_ahead { F } if wall_left { R } else { L }",
+			&Program{main: []statement{
+				{kind: while, cond: condition{sensor: ahead, not: true}, body: []statement{{kind: cmds, text: "F", pos: 20}}, pos: 0},
+				{kind: cond, cond: condition{sensor: left}, body: []statement{{kind: cmds, text: "R", pos: 39}}, alt: []statement{{kind: cmds, text: "L", pos: 50}}, pos: 24},
+			}, procs: map[string][]statement{}},
+		},
+		{
+			"go proc go { repeat 2 { F } }",
+			&Program{main: []statement{{kind: call, text: "go", pos: 0}}, procs: map[string][]statement{
+				"go": {{kind: repeat, n: 2, body: []statement{{kind: cmds, text: "F", pos: 24}}, pos: 13}},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("Test parse: %q", tt.src), func(t *testing.T) {
+			got, err := Parse(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want error
+	}{
+		{"F ?", ErrSyntax{Index: 2, Msg: "unexpected '?'"}},
+		{"while wall_ahead F", ErrSyntax{Index: 17, Msg: "expected '{'"}},
+		{"while { F }", ErrSyntax{Index: 6, Msg: "expected wall_ahead, wall_left or wall_right"}},
+		{"if !wall_up { F }", ErrSyntax{Index: 4, Msg: "expected wall_ahead, wall_left or wall_right"}},
+		{"repeat { F }", ErrSyntax{Index: 7, Msg: "expected a count between 0 and 4294967295"}},
+		{"repeat 4294967296 { F }", ErrSyntax{Index: 7, Msg: "expected a count between 0 and 4294967295"}},
+		{"repeat 2 { F", ErrSyntax{Index: 12, Msg: "missing '}'"}},
+		{"F }", ErrSyntax{Index: 2, Msg: `unexpected "}"`}},
+		{"else { F }", ErrSyntax{Index: 0, Msg: `unexpected "else"`}},
+		{"!", ErrSyntax{Index: 0, Msg: `unexpected "!"`}},
+		{"proc fl { F }", ErrSyntax{Index: 5, Msg: "expected a procedure name"}},
+		{"proc while { F }", ErrSyntax{Index: 5, Msg: "expected a procedure name"}},
+		{"proc a { F } proc a { L }", ErrSyntax{Index: 18, Msg: `procedure "a" is already defined`}},
+		{"proc a { proc b { F } }", ErrSyntax{Index: 9, Msg: "procedures can only be defined at the top level"}},
+		{"proc a { if wall_left { x } }", ErrSyntax{Index: 24, Msg: `procedure "x" is not defined`}},
+		{"proc a", ErrSyntax{Index: 6, Msg: "expected '{'"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("Test parse error: %q", tt.src), func(t *testing.T) {
+			if _, err := Parse(tt.src); err != tt.want {
+				t.Errorf("Got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
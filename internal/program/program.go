@@ -0,0 +1,75 @@
+/*
+Package program interprets small programs that decide what a robot does next by looking at its distance sensors.
+
+Programs are parsed according to the following grammar:
+
+	program   = { procedure | statement } ;
+	procedure = "proc" name block ;
+	statement = commands | name | "while" condition block | "if" condition block [ "else" block ] | "repeat" count block ;
+	condition = [ "!" ] ( "wall_ahead" | "wall_left" | "wall_right" ) ;
+	block     = "{" { statement } "}" ;
+	commands  = command { command } ;
+	command   = "L" | "R" | "F" | "B" | "<" | ">" ;
+	name      = ( letter | "_" ) { letter | digit | "_" } ;
+	count     = digit { digit } ;
+
+Commands are case-insensitive and mean the same as in a command string, e.g. "FFR" or "f f r". Keywords and conditions are lower case.
+A name calls the procedure with that name. Procedures are defined at the top level, can be called before they are defined and can call themselves.
+A name that only consists of commands, e.g. "fl", is always commands and can not be used as a procedure name.
+Statements are separated by white space and everything from a "#" to the end of the line is a comment. For example:
+
+	# Drive along the walls of the room, turning left in the corners.
+	proc turn { if wall_ahead { L } }
+	repeat 4 { while !wall_ahead { F } turn }
+*/
+package program
+
+// The deepest procedure calls can be nested.
+const maxDepth = 1000
+
+// Program is a parsed program that can be run on any number of robots.
+type Program struct {
+	main  []statement
+	procs map[string][]statement
+}
+
+type kind uint8
+
+const (
+	cmds kind = iota
+	call
+	while
+	cond
+	repeat
+)
+
+// statement is a single statement of a program. Which fields are used depends on the kind.
+type statement struct {
+	kind kind
+	// The commands of a cmds statement or the procedure name of a call.
+	text string
+	cond condition
+	body []statement
+	// The else block of an if statement.
+	alt []statement
+	// The count of a repeat statement.
+	n uint
+	// Position of the first rune of the statement in the source.
+	pos int
+}
+
+type sensor uint8
+
+const (
+	ahead sensor = iota
+	left
+	right
+)
+
+var sensorNames = map[string]sensor{"wall_ahead": ahead, "wall_left": left, "wall_right": right}
+
+// condition is true if there is a wall in the direction of the sensor, or false if it is negated.
+type condition struct {
+	sensor sensor
+	not    bool
+}
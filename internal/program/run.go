@@ -0,0 +1,118 @@
+package program
+
+import (
+	"github.com/anfly0/cuddly-octo-bassoon/internal/robot"
+)
+
+// machine is the state of a running program.
+type machine struct {
+	prog  *Program
+	d     *robot.Driver
+	limit int
+	ticks int
+}
+
+/*
+Run runs the program on the robot. The robot executes one command at a time and the conditions are evaluated with the distance sensors of the robot right before they are needed, see robot.Robot.Sense.
+A wall condition is true when the robot can not move in that direction because of a wall, an obstacle or another robot.
+Result.Steps is the number of commands the robot executed. The program is executed as a single command batch, so it can be undone with robot.Robot.Undo, and it is stopped by the same errors as robot.Robot.Cmd.
+To make sure that every program ends, it is stopped with an ErrStepLimit when the interpreter has taken limit steps. Every command, evaluated condition, procedure call and round of a repeat loop counts as a step, so loops that never move the robot end as well.
+*/
+func (prog *Program) Run(rb *robot.Robot, limit int, opts ...robot.CmdOption) (robot.Result, error) {
+	return rb.Drive(func(d *robot.Driver) error {
+		m := &machine{prog: prog, d: d, limit: limit}
+		return m.block(prog.main, 0)
+	}, opts...)
+}
+
+// Counts a step. Returns ErrStepLimit if the step limit is reached.
+func (m *machine) tick() error {
+	if m.ticks >= m.limit {
+		return ErrStepLimit{Limit: m.limit}
+	}
+
+	m.ticks++
+	return nil
+}
+
+// Executes the statements. Depth is the number of procedure calls the statements are nested in.
+func (m *machine) block(body []statement, depth int) error {
+	for _, st := range body {
+		if err := m.statement(st, depth); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *machine) statement(st statement, depth int) error {
+	switch st.kind {
+	case cmds:
+		for _, c := range st.text {
+			if err := m.tick(); err != nil {
+				return err
+			}
+			if err := m.d.Step(c); err != nil {
+				return err
+			}
+		}
+	case call:
+		if depth >= maxDepth {
+			return ErrTooDeep
+		}
+		if err := m.tick(); err != nil {
+			return err
+		}
+		return m.block(m.prog.procs[st.text], depth+1)
+	case while:
+		for {
+			ok, err := m.eval(st.cond)
+			if err != nil || !ok {
+				return err
+			}
+			if err := m.block(st.body, depth); err != nil {
+				return err
+			}
+		}
+	case cond:
+		ok, err := m.eval(st.cond)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return m.block(st.body, depth)
+		}
+		return m.block(st.alt, depth)
+	case repeat:
+		for i := uint(0); i < st.n; i++ {
+			if err := m.tick(); err != nil {
+				return err
+			}
+			if err := m.block(st.body, depth); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Evaluates a condition with the sensors of the robot.
+func (m *machine) eval(c condition) (bool, error) {
+	if err := m.tick(); err != nil {
+		return false, err
+	}
+
+	s := m.d.Sense()
+	rd := s.Front
+	switch c.sensor {
+	case left:
+		rd = s.Left
+	case right:
+		rd = s.Right
+	}
+
+	wall := rd.Distance == 0 && rd.Obstruction != robot.Nothing
+	return wall != c.not, nil
+}
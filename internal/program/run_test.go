@@ -0,0 +1,82 @@
+package program
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/anfly0/cuddly-octo-bassoon/internal/robot"
+)
+
+func TestRun(t *testing.T) {
+	room := robot.Room{X: 5, Y: 5, Obstacles: []robot.Coordinate{{X: 2, Y: 4}}}
+
+	tests := []struct {
+		src       string
+		d         string
+		limit     int
+		opts      []robot.Option
+		wantD     string
+		wantC     robot.Coordinate
+		wantSteps int
+		wantErr   error
+	}{
+		{"while !wall_ahead { F }", "N", 100, nil, "N", robot.Coordinate{X: 0, Y: 0}, 4, nil},
+		{"proc side { while !wall_ahead { F } R } repeat 2 { side }", "N", 100, nil, "S", robot.Coordinate{X: 4, Y: 0}, 10, nil},
+		{"if wall_left { R } else { L }", "N", 100, nil, "E", robot.Coordinate{X: 0, Y: 4}, 1, nil},
+		{"if !wall_right { R } else { L }", "N", 100, nil, "E", robot.Coordinate{X: 0, Y: 4}, 1, nil},
+		// The obstacle counts as a wall.
+		{"while !wall_ahead { F }", "E", 100, nil, "E", robot.Coordinate{X: 1, Y: 4}, 1, nil},
+		{"repeat 3 { f }", "N", 100, nil, "N", robot.Coordinate{X: 0, Y: 1}, 3, nil},
+		// Limits are reached by loops that do not move the robot too.
+		{"while !wall_ahead { F }", "N", 3, nil, "N", robot.Coordinate{X: 0, Y: 3}, 1, ErrStepLimit{Limit: 3}},
+		{"while wall_ahead { }", "W", 100, nil, "W", robot.Coordinate{X: 0, Y: 4}, 0, ErrStepLimit{Limit: 100}},
+		{"repeat 4294967295 { }", "W", 100, nil, "W", robot.Coordinate{X: 0, Y: 4}, 0, ErrStepLimit{Limit: 100}},
+		{"proc spin { L spin } spin", "N", 100000, nil, "N", robot.Coordinate{X: 0, Y: 4}, 1000, ErrTooDeep},
+		// Errors from the robot stop the program.
+		{"F F", "W", 100, []robot.Option{robot.WithCollisionPolicy(robot.Stop)}, "W", robot.Coordinate{X: 0, Y: 4}, 1, robot.ErrCollision{Step: 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("Test run: %q %s", tt.src, tt.d), func(t *testing.T) {
+			prog, err := Parse(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rb, err := robot.NewRobot(room, tt.d, robot.Coordinate{X: 0, Y: 4}, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res, err := prog.Run(rb, tt.limit)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Got error %v, want %v", err, tt.wantErr)
+			}
+			if res.Direction != tt.wantD || res.Coordinate != tt.wantC || res.Steps != tt.wantSteps {
+				t.Errorf("Got %s %v after %d steps, want %s %v after %d steps", res.Direction, res.Coordinate, res.Steps, tt.wantD, tt.wantC, tt.wantSteps)
+			}
+		})
+	}
+}
+
+func TestRunUndo(t *testing.T) {
+	prog, err := Parse("while !wall_ahead { F } R F")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rb, err := robot.NewRobot(robot.Room{X: 3, Y: 3}, "N", robot.Coordinate{X: 0, Y: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res, err := prog.Run(rb, 100, robot.WithTrace()); err != nil || len(res.Trace) != 4 {
+		t.Fatalf("Got %+v %v, want a trace of 4 steps", res, err)
+	}
+
+	// The whole program is undone at once.
+	if d, c, err := rb.Undo(); err != nil || d != "N" || c != (robot.Coordinate{X: 0, Y: 2}) {
+		t.Errorf("Got %s %v %v, want N {0 2}", d, c, err)
+	}
+}
@@ -0,0 +1,53 @@
+package robot
+
+// Coverage is a record of how many times a robot has entered each cell of its room.
+type Coverage struct {
+	// The number of visits per cell, Visits[y][x]. Blocked cells are never visited.
+	Visits [][]uint32
+	// The number of free cells in the room and the number of them that have been visited at least once.
+	Free    int
+	Visited int
+}
+
+// Returns the percentage of the free cells in the room that have been visited.
+func (c Coverage) Percent() float64 {
+	if c.Free == 0 {
+		return 0
+	}
+	return float64(c.Visited) * 100 / float64(c.Free)
+}
+
+// Counts a visit to the cell. Copies of the robot, see clone, do not count visits.
+func (r *Robot) visit(c Coordinate) {
+	if r.visits != nil {
+		r.visits[c.Y*r.room.X+c.X]++
+	}
+}
+
+/*
+Coverage returns how many times the robot has entered each cell of its room while executing command strings, including Goto.
+The cell the robot was created in counts as one visit. Undo and redo move the robot without visiting any cells.
+*/
+func (r *Robot) Coverage() Coverage {
+	r.l.RLock()
+	defer r.l.RUnlock()
+
+	res := Coverage{Visits: make([][]uint32, r.room.Y)}
+
+	for y := uint(0); y < r.room.Y; y++ {
+		res.Visits[y] = append([]uint32(nil), r.visits[y*r.room.X:(y+1)*r.room.X]...)
+
+		for x := uint(0); x < r.room.X; x++ {
+			if !r.room.free(Coordinate{X: x, Y: y}) {
+				continue
+			}
+
+			res.Free++
+			if res.Visits[y][x] > 0 {
+				res.Visited++
+			}
+		}
+	}
+
+	return res
+}
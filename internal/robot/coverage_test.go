@@ -0,0 +1,66 @@
+package robot
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRobotCoverage(t *testing.T) {
+	r, err := NewRobot(Room{X: 3, Y: 2, Obstacles: []Coordinate{{X: 2, Y: 1}}}, "E", Coordinate{X: 0, Y: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cov := r.Coverage()
+	if cov.Free != 5 || cov.Visited != 1 || cov.Percent() != 20 {
+		t.Errorf("Got %+v %v%%, want 5 free 1 visited 20%%", cov, cov.Percent())
+	}
+
+	// Bumps into the wall and obstacle do not count as visits.
+	r.Cmd("FFFRFF")
+	// Neither do simulations, undo and redo.
+	r.Simulate("RRFF")
+	r.Undo()
+	r.Redo()
+	r.Cmd("RFLF")
+
+	cov = r.Coverage()
+	want := [][]uint32{
+		{1, 2, 1},
+		{0, 1, 0},
+	}
+	if !reflect.DeepEqual(cov.Visits, want) || cov.Free != 5 || cov.Visited != 4 || cov.Percent() != 80 {
+		t.Errorf("Got %+v %v%%, want %v 4 visited 80%%", cov, cov.Percent(), want)
+	}
+
+	// The matrix is a copy.
+	cov.Visits[0][0] = 10
+	if r.Coverage().Visits[0][0] != 1 {
+		t.Error("Changing the coverage changed the robot")
+	}
+}
+
+func TestRobotCoverageManyObstacles(t *testing.T) {
+	// The largest room the API accepts, with every fifth cell blocked.
+	room := Room{X: 1000, Y: 1000}
+	for i := uint(0); i < room.X*room.Y; i += 5 {
+		room.Obstacles = append(room.Obstacles, Coordinate{X: i % room.X, Y: i / room.X})
+	}
+
+	r, err := NewRobot(room, "E", Coordinate{X: 1, Y: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Looking up every cell must not go through all obstacles, which would take minutes.
+	start := time.Now()
+	cov := r.Coverage()
+	if d := time.Since(start); d > 10*time.Second {
+		t.Errorf("Coverage took %v", d)
+	}
+
+	if cov.Free != 800000 || cov.Visited != 1 {
+		t.Errorf("Got %d free %d visited, want 800000 free 1 visited", cov.Free, cov.Visited)
+	}
+}
@@ -0,0 +1,61 @@
+package robot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Full names of the directions, in the same order as directions.
+var directionNames = []string{"north", "northeast", "east", "southeast", "south", "southwest", "west", "northwest"}
+
+/*
+ParseDirection parses a direction and returns its abbreviation, e.g. "N" or "NE". The following formats are accepted:
+  - abbreviations, e.g. "N", "ne"
+  - full names, e.g. "north", "East", "north-east", "South West"
+  - compass bearings in degrees, clockwise from north, e.g. "0", "90", "-45", "270°"
+
+Only the directions available in the given compass mode are accepted, e.g. "NE" and "45" are invalid for a four-point compass.
+*/
+func ParseDirection(s string, m CompassMode) (string, error) {
+	index, err := parseDirection(s, m)
+	if err != nil {
+		return "", err
+	}
+
+	return directions[index], nil
+}
+
+// Parses a direction and returns its index in directions.
+func parseDirection(s string, m CompassMode) (uint, error) {
+	trimmed := strings.ToLower(strings.TrimSpace(s))
+	d := strings.NewReplacer("-", "", "_", "", " ", "").Replace(trimmed)
+
+	if d == "" {
+		return 0, fmt.Errorf("%w: the direction must not be empty", ErrInvalidDirection)
+	}
+
+	index := -1
+	for i := range directions {
+		if d == strings.ToLower(directions[i]) || d == directionNames[i] {
+			index = i
+		}
+	}
+
+	if deg, err := strconv.Atoi(strings.TrimSuffix(trimmed, "°")); err == nil {
+		if deg%45 != 0 {
+			return 0, fmt.Errorf("%w %q, degrees must be a multiple of %d", ErrInvalidDirection, s, 45*m.step())
+		}
+		index = ((deg/45)%len(directions) + len(directions)) % len(directions)
+	}
+
+	if index < 0 {
+		return 0, fmt.Errorf("%w %q", ErrInvalidDirection, s)
+	}
+
+	if uint(index)%m.step() != 0 {
+		return 0, fmt.Errorf("%w %q, the direction is not available on a four-point compass", ErrInvalidDirection, s)
+	}
+
+	return uint(index), nil
+}
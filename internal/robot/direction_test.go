@@ -0,0 +1,60 @@
+package robot
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestParseDirection(t *testing.T) {
+	tests := []struct {
+		s    string
+		m    CompassMode
+		want string
+	}{
+		{"N", FourPoint, "N"},
+		{"w", FourPoint, "W"},
+		{"north", FourPoint, "N"},
+		{"East", FourPoint, "E"},
+		{" SOUTH ", FourPoint, "S"},
+		{"0", FourPoint, "N"},
+		{"90", FourPoint, "E"},
+		{"270°", FourPoint, "W"},
+		{"360", FourPoint, "N"},
+		{"-90", FourPoint, "W"},
+		{"NE", EightPoint, "NE"},
+		{"north-east", EightPoint, "NE"},
+		{"South West", EightPoint, "SW"},
+		{"northwest", EightPoint, "NW"},
+		{"south_east", EightPoint, "SE"},
+		{"45", EightPoint, "NE"},
+		{"-45", EightPoint, "NW"},
+		{"", FourPoint, ""},
+		{"Xyz", FourPoint, ""},
+		{"X", EightPoint, ""},
+		{"NE", FourPoint, ""},
+		{"north-east", FourPoint, ""},
+		{"45", FourPoint, ""},
+		{"30", EightPoint, ""},
+		{"9O", FourPoint, ""},
+	}
+
+	for _, tt := range tests {
+		tname := fmt.Sprintf("ParseDirection: %q %d", tt.s, tt.m)
+
+		t.Run(tname, func(t *testing.T) {
+			got, err := ParseDirection(tt.s, tt.m)
+
+			if tt.want == "" {
+				if !errors.Is(err, ErrInvalidDirection) {
+					t.Errorf("Got %s %v, want %v", got, err, ErrInvalidDirection)
+				}
+				return
+			}
+
+			if err != nil || got != tt.want {
+				t.Errorf("Got %s %v, want %s", got, err, tt.want)
+			}
+		})
+	}
+}
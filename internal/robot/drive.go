@@ -0,0 +1,66 @@
+package robot
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Driver executes a command batch on a robot one step at a time, see Robot.Drive.
+type Driver struct {
+	r    *Robot
+	cfg  cmdConfig
+	res  Result
+	cmds strings.Builder
+}
+
+/*
+Drive executes a command batch that is decided one step at a time by fn, e.g. by an interpreter that looks at the sensors before every step.
+The robot is locked while fn runs, so fn must use the driver rather than the robot. The batch is recorded in the history and the event log as the commands that were executed, so it can be undone and replayed like any other command batch.
+If fn returns an error, or a step fails for the same reasons as in Cmd, the execution stops and the state of the robot is returned together with the error.
+*/
+func (r *Robot) Drive(fn func(d *Driver) error, opts ...CmdOption) (Result, error) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	res := Result{}
+	res.Direction, res.Coordinate = r.report()
+
+	if r.busy() {
+		return res, ErrBusy
+	}
+
+	d := &Driver{r: r, cfg: newCmdConfig(opts)}
+
+	r.record()
+	err := fn(d)
+	r.emit(EventCmd, d.cmds.String())
+
+	res = d.res
+	res.Direction, res.Coordinate = r.report()
+	return res, err
+}
+
+// Step executes a single command. Returns ErrInvalidCommand if c is not a valid command.
+func (d *Driver) Step(c rune) error {
+	c = unicode.ToUpper(c)
+	if !strings.ContainsRune(commands, c) {
+		return ErrInvalidCommand{Index: d.res.Steps, Rune: c}
+	}
+
+	steps := d.res.Steps
+	err := d.r.exec(c, d.cfg, &d.res)
+	if d.res.Steps > steps {
+		d.cmds.WriteRune(c)
+	}
+	return err
+}
+
+// Sense returns the readings of the distance sensors of the robot, see Robot.Sense.
+func (d *Driver) Sense() Sensors {
+	return d.r.sense()
+}
+
+// Steps returns the number of steps that have been executed, including steps that collided.
+func (d *Driver) Steps() int {
+	return d.res.Steps
+}
@@ -0,0 +1,69 @@
+package robot
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRobotDrive(t *testing.T) {
+	var events []Event
+	r, err := NewRobot(Room{X: 5, Y: 5}, "N", Coordinate{X: 0, Y: 4}, WithEventSink(func(e Event) {
+		events = append(events, e)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Drive to the wall in front and then one step too far.
+	res, err := r.Drive(func(d *Driver) error {
+		for d.Sense().Front.Distance > 0 {
+			if err := d.Step('f'); err != nil {
+				return err
+			}
+		}
+		if err := d.Step('F'); err != nil {
+			return err
+		}
+		if d.Steps() != 5 {
+			t.Errorf("Got %d steps, want 5", d.Steps())
+		}
+		return d.Step('X')
+	}, WithTrace())
+
+	if err != (ErrInvalidCommand{Index: 5, Rune: 'X'}) {
+		t.Errorf("Got %v, want an invalid command", err)
+	}
+	if res.Direction != "N" || res.Coordinate != (Coordinate{X: 0, Y: 0}) || !reflect.DeepEqual(res.Collisions, []int{4}) || len(res.Trace) != 5 {
+		t.Errorf("Got %+v", res)
+	}
+
+	// The executed commands are recorded like any other command batch.
+	if len(events) != 2 || events[1].Kind != EventCmd || events[1].Cmd != "FFFFF" {
+		t.Errorf("Got events %+v", events)
+	}
+	if _, c, err := r.Undo(); err != nil || c != (Coordinate{X: 0, Y: 4}) {
+		t.Errorf("Got %v %v, want {0 4}", c, err)
+	}
+}
+
+func TestRobotDriveOutOfEnergy(t *testing.T) {
+	r, err := NewRobot(Room{X: 5, Y: 5}, "N", Coordinate{X: 0, Y: 4}, WithBattery(2, EnergyCosts{F: 1, L: 1, R: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := 0
+	res, err := r.Drive(func(d *Driver) error {
+		defer func() { steps = d.Steps() }()
+		for {
+			if err := d.Step('F'); err != nil {
+				return err
+			}
+		}
+	})
+
+	if !errors.Is(err, ErrOutOfEnergy{Step: 2}) || steps != 2 || res.Coordinate != (Coordinate{X: 0, Y: 2}) {
+		t.Errorf("Got %+v %v after %d steps, want {0 2} and out of energy after 2 steps", res, err, steps)
+	}
+}
@@ -0,0 +1,102 @@
+package robot
+
+// EnergyCosts is the amount of energy each command uses. B, < and > use the same amount as F.
+type EnergyCosts struct {
+	F uint `json:"f"`
+	L uint `json:"l"`
+	R uint `json:"r"`
+}
+
+// battery is the energy budget of a robot.
+type battery struct {
+	capacity uint
+	level    uint
+	costs    EnergyCosts
+}
+
+/*
+Gives the robot a battery with the given capacity that starts fully charged. Every command uses energy according to the costs, also moves that collide.
+When the battery does not have enough energy left for the next command, the execution of the command string is stopped with an ErrOutOfEnergy.
+The battery is charged to full capacity with Charge or when the robot is in a cell with a charging station, see Room.Chargers.
+Robots without a battery never run out of energy.
+*/
+func WithBattery(capacity uint, costs EnergyCosts) Option {
+	return func(r *Robot) {
+		r.battery = &battery{capacity: capacity, level: capacity, costs: costs}
+	}
+}
+
+// Returns the amount of energy the command uses.
+func (b *battery) cost(c rune) uint {
+	switch c {
+	case 'L':
+		return b.costs.L
+	case 'R':
+		return b.costs.R
+	default:
+		return b.costs.F
+	}
+}
+
+// Uses the energy for the command. Returns false, without using any energy, if there is not enough energy left. Robots without a battery always have enough energy.
+func (r *Robot) use(c rune) bool {
+	if r.battery == nil {
+		return true
+	}
+
+	cost := r.battery.cost(c)
+	if cost > r.battery.level {
+		return false
+	}
+
+	r.battery.level -= cost
+	return true
+}
+
+// Charges the battery to full capacity if there is a charging station in the cell the robot is in.
+func (r *Robot) chargeAtStation() {
+	if r.battery != nil && r.room.charger(r.coordinate) {
+		r.battery.level = r.battery.capacity
+	}
+}
+
+// Returns the energy left in the battery and its capacity. If the robot has no battery ok is false.
+func (r *Robot) Energy() (level uint, capacity uint, ok bool) {
+	r.l.RLock()
+	defer r.l.RUnlock()
+
+	if r.battery == nil {
+		return 0, 0, false
+	}
+	return r.battery.level, r.battery.capacity, true
+}
+
+// Charges the battery of the robot to full capacity and returns the energy level. Returns ErrNoBattery if the robot has no battery.
+// Like Undo, ErrBusy is returned while queued command strings are executed or a command string is streamed, since the charge would be logged in the middle of a command batch.
+func (r *Robot) Charge() (uint, error) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	if r.battery == nil {
+		return 0, ErrNoBattery
+	}
+	if r.busy() {
+		return 0, ErrBusy
+	}
+
+	r.battery.level = r.battery.capacity
+	r.emit(EventCharge, "")
+
+	return r.battery.level, nil
+}
+
+// Returns true if there is a charging station in the cell.
+func (r Room) charger(c Coordinate) bool {
+	for _, ch := range r.Chargers {
+		if ch == c {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,106 @@
+package robot
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRobotBattery(t *testing.T) {
+	room := Room{X: 5, Y: 1, Chargers: []Coordinate{{X: 4, Y: 0}}}
+	r, err := NewRobot(room, "E", Coordinate{X: 0, Y: 0}, WithBattery(5, EnergyCosts{F: 2, L: 1, R: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The step that the battery can not afford is not executed.
+	res, err := r.Cmd("FFF")
+	if err != (ErrOutOfEnergy{Step: 2}) || res.Coordinate != (Coordinate{X: 2, Y: 0}) {
+		t.Errorf("Got %+v %v", res, err)
+	}
+	if level, capacity, ok := r.Energy(); level != 1 || capacity != 5 || !ok {
+		t.Errorf("Got energy %d/%d %v, want 1/5", level, capacity, ok)
+	}
+
+	// Simulations use the energy of a copy of the battery.
+	for i := 0; i < 2; i++ {
+		if _, err = r.Simulate("L"); err != nil {
+			t.Errorf("Got %v", err)
+		}
+	}
+
+	// Turns are cheaper.
+	if res, err = r.Cmd("L"); err != nil || res.Direction != "N" {
+		t.Errorf("Got %+v %v", res, err)
+	}
+	if _, err = r.Simulate("R"); err != (ErrOutOfEnergy{Step: 0}) {
+		t.Errorf("Got %v", err)
+	}
+
+	if level, err := r.Charge(); level != 5 || err != nil {
+		t.Errorf("Got %d %v, want 5", level, err)
+	}
+
+	// The charging station fills the battery, also when the robot collides there.
+	if res, err = r.Cmd("RFFFF"); err != nil || res.Coordinate != (Coordinate{X: 4, Y: 0}) {
+		t.Errorf("Got %+v %v", res, err)
+	}
+	if level, _, _ := r.Energy(); level != 5 {
+		t.Errorf("Got energy %d, want 5", level)
+	}
+	// Only the step that leaves the station uses energy.
+	r.Cmd("LLF")
+	if level, _, _ := r.Energy(); level != 3 {
+		t.Errorf("Got energy %d, want 3", level)
+	}
+}
+
+func TestRobotWithoutBattery(t *testing.T) {
+	r, err := NewRobot(Room{X: 2, Y: 2}, "N", Coordinate{X: 0, Y: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, ok := r.Energy(); ok {
+		t.Error("Got a battery, want none")
+	}
+	if _, err := r.Charge(); !errors.Is(err, ErrNoBattery) {
+		t.Errorf("Got %v, want %v", err, ErrNoBattery)
+	}
+	if _, err := r.Cmd("100[LR]"); err != nil {
+		t.Errorf("Got %v", err)
+	}
+}
+
+func TestRobotChargeBusy(t *testing.T) {
+	var events []Event
+	r, err := NewRobot(Room{X: 5, Y: 5}, "N", Coordinate{X: 0, Y: 4}, WithBattery(10, EnergyCosts{}), WithTiming(Timing{Move: 5 * time.Millisecond}), WithEventSink(func(e Event) {
+		events = append(events, e)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Submit("4F"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Charge(); !errors.Is(err, ErrBusy) {
+		t.Errorf("Got %v, want %v", err, ErrBusy)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(r.Queue()) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the queue was not emptied in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// The log can be replayed, since nothing was logged in the middle of the job.
+	if level, err := r.Charge(); level != 10 || err != nil {
+		t.Errorf("Got %d %v, want 10", level, err)
+	}
+	if _, err := Replay(Room{X: 5, Y: 5}, events, WithBattery(10, EnergyCosts{})); err != nil {
+		t.Error(err)
+	}
+}
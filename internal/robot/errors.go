@@ -0,0 +1,93 @@
+package robot
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Errors returned by the robot package. Use errors.Is to check for them.
+var (
+	ErrOutsideRoom      = errors.New("the robot coordinates are outside the room")
+	ErrBlockedCell      = errors.New("the robot coordinates are on a blocked cell")
+	ErrOccupied         = errors.New("the robot coordinates are occupied by another robot")
+	ErrInvalidDirection = errors.New("invalid direction")
+	ErrEmptyRoom        = errors.New("the room must be at least 1x1")
+	ErrRoomTooLarge     = fmt.Errorf("the room must not have more than %d cells", maxCells)
+	ErrMapMismatch      = errors.New("the room dimensions do not match the map")
+	ErrInvalidMap       = errors.New("invalid map")
+	ErrRoomClosed       = errors.New("the room is closed")
+	ErrRoomNotEmpty     = errors.New("the room can not be closed while there are robots in it")
+	ErrInvalidPolicy    = errors.New("invalid collision policy")
+	ErrNothingToUndo    = errors.New("there is nothing to undo")
+	ErrNothingToRedo    = errors.New("there is nothing to redo")
+	ErrInvalidLog       = errors.New("invalid event log")
+	ErrUnreachable      = errors.New("the target can not be reached")
+	ErrNoBattery        = errors.New("the robot has no battery")
+	ErrNotTimed         = errors.New("the robot does not execute commands in simulated time")
+	ErrBusy             = errors.New("the robot is busy executing queued commands")
+	ErrTooManySteps     = fmt.Errorf("the command string has more than %d steps", maxSteps)
+)
+
+// ErrInvalidCommand is returned when a command string contains a rune that is not a valid command.
+// Index is the position of the rune in the command string.
+type ErrInvalidCommand struct {
+	Index int
+	Rune  rune
+}
+
+func (e ErrInvalidCommand) Error() string {
+	return fmt.Sprintf("invalid command %q at position %d, valid commands are %s", e.Rune, e.Index, strings.Join(strings.Split(commands, ""), ", "))
+}
+
+// ErrSyntax is returned when a command string is not valid according to the grammar for any other reason than an invalid command, e.g. a missing ']'.
+// Index is the position in the command string where the problem was found.
+type ErrSyntax struct {
+	Index int
+	Msg   string
+}
+
+func (e ErrSyntax) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Index)
+}
+
+// ErrCollision is returned when a robot with the Stop collision policy collides. Step is the index of the step that collided.
+type ErrCollision struct {
+	Step int
+}
+
+func (e ErrCollision) Error() string {
+	return fmt.Sprintf("the robot collided with a wall, a blocked cell or another robot at step %d", e.Step)
+}
+
+// ErrReplayMismatch is returned by Replay when the state of the replayed robot differs from the state recorded in an event. Index is the index of the event.
+type ErrReplayMismatch struct {
+	Index int
+}
+
+func (e ErrReplayMismatch) Error() string {
+	return fmt.Sprintf("the replayed state does not match the event at index %d", e.Index)
+}
+
+// ErrOutOfEnergy is returned when the battery of a robot does not have enough energy left for a step. Step is the index of the step, which was not executed.
+type ErrOutOfEnergy struct {
+	Step int
+}
+
+func (e ErrOutOfEnergy) Error() string {
+	return fmt.Sprintf("the robot ran out of energy at step %d", e.Step)
+}
+
+// ErrCanceled is returned when the context of a command string is done before the command string has been executed to the end. Step is the index of the first step that was not executed and Err the error of the context.
+type ErrCanceled struct {
+	Step int
+	Err  error
+}
+
+func (e ErrCanceled) Error() string {
+	return fmt.Sprintf("the execution was canceled at step %d: %v", e.Step, e.Err)
+}
+
+func (e ErrCanceled) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,94 @@
+package robot
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// EventKind is the type of change to the state of a robot that an event records.
+type EventKind string
+
+const (
+	EventCreate EventKind = "create"
+	EventCmd    EventKind = "cmd"
+	EventUndo   EventKind = "undo"
+	EventRedo   EventKind = "redo"
+	EventCharge EventKind = "charge"
+)
+
+/*
+Event records a change to the state of a robot: its creation, an accepted command batch, an undo/redo or a charge of its battery.
+Direction and Coordinate are the state of the robot after the change. A robot can be rebuilt from its events with Replay.
+*/
+type Event struct {
+	Time       time.Time  `json:"time"`
+	Kind       EventKind  `json:"kind"`
+	Cmd        string     `json:"cmd,omitempty"`
+	Direction  string     `json:"direction"`
+	Coordinate Coordinate `json:"coordinate"`
+}
+
+/*
+Sets a function that is called with an event every time the state of the robot changes.
+The function is called while the robot is locked, so events for one robot are always delivered in the order they happened. It must not call any methods on the robot.
+*/
+func WithEventSink(fn func(Event)) Option {
+	return func(r *Robot) {
+		r.sink = fn
+	}
+}
+
+// Sends an event with the current state of the robot to the event sink, if there is one.
+func (r *Robot) emit(kind EventKind, cs string) {
+	if r.sink == nil {
+		return
+	}
+
+	d, c := r.report()
+	r.sink(Event{Time: time.Now(), Kind: kind, Cmd: cs, Direction: d, Coordinate: c})
+}
+
+/*
+Replay rebuilds a robot in the room from its events. The first event must be the creation of the robot and the options should be the same as when the robot was created.
+Every command batch, undo and redo is executed again and the resulting state is compared to the state recorded in the event.
+The replayed robot has a room of its own, so a robot from a shared room can only be replayed if it never collided with another robot.
+*/
+func Replay(room Room, events []Event, opts ...Option) (*Robot, error) {
+	if len(events) == 0 || events[0].Kind != EventCreate {
+		return nil, fmt.Errorf("%w: the first event must be %q", ErrInvalidLog, EventCreate)
+	}
+
+	rb, err := NewRobot(room, events[0].Direction, events[0].Coordinate, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, e := range events[1:] {
+		switch e.Kind {
+		case EventCmd:
+			_, err = rb.Cmd(e.Cmd)
+			if errors.As(err, &ErrCollision{}) || errors.As(err, &ErrOutOfEnergy{}) {
+				err = nil
+			}
+		case EventUndo:
+			_, _, err = rb.Undo()
+		case EventRedo:
+			_, _, err = rb.Redo()
+		case EventCharge:
+			_, err = rb.Charge()
+		default:
+			err = fmt.Errorf("%w: unexpected event %q at index %d", ErrInvalidLog, e.Kind, i+1)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if d, c := rb.Report(); d != e.Direction || c != e.Coordinate {
+			return nil, ErrReplayMismatch{Index: i + 1}
+		}
+	}
+
+	return rb, nil
+}
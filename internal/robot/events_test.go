@@ -0,0 +1,118 @@
+package robot
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRobotEvents(t *testing.T) {
+	var events []Event
+	room := Room{X: 5, Y: 5}
+
+	r, err := NewRobot(room, "N", Coordinate{X: 2, Y: 2}, WithEventSink(func(e Event) {
+		events = append(events, e)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.Cmd("FF")
+	r.Cmd("RF")
+	// Command strings that can not be parsed are not recorded.
+	r.Cmd("X")
+	r.Undo()
+	r.Redo()
+	r.Undo()
+	r.Cmd("3F")
+
+	want := []struct {
+		kind EventKind
+		cmd  string
+		d    string
+		c    Coordinate
+	}{
+		{EventCreate, "", "N", Coordinate{X: 2, Y: 2}},
+		{EventCmd, "FF", "N", Coordinate{X: 2, Y: 0}},
+		{EventCmd, "RF", "E", Coordinate{X: 3, Y: 0}},
+		{EventUndo, "", "N", Coordinate{X: 2, Y: 0}},
+		{EventRedo, "", "E", Coordinate{X: 3, Y: 0}},
+		{EventUndo, "", "N", Coordinate{X: 2, Y: 0}},
+		{EventCmd, "3F", "N", Coordinate{X: 2, Y: 0}},
+	}
+
+	if len(events) != len(want) {
+		t.Fatalf("Got %d events, want %d", len(events), len(want))
+	}
+
+	for i, w := range want {
+		e := events[i]
+		if e.Kind != w.kind || e.Cmd != w.cmd || e.Direction != w.d || e.Coordinate != w.c || e.Time.IsZero() {
+			t.Errorf("Event %d: got %+v, want %+v", i, e, w)
+		}
+	}
+
+	replayed, err := Replay(room, events)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, c := replayed.Report()
+	if d != "N" || c != (Coordinate{X: 2, Y: 0}) {
+		t.Errorf("Got %s %+v, want N {X:2 Y:0}", d, c)
+	}
+
+	// The history is rebuilt as well.
+	replayed.Undo()
+	if d, c, err := replayed.Undo(); err != nil || d != "N" || c != (Coordinate{X: 2, Y: 2}) {
+		t.Errorf("Got %s %+v %v, want N {X:2 Y:2}", d, c, err)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	room := Room{X: 3, Y: 3}
+	create := Event{Kind: EventCreate, Direction: "N", Coordinate: Coordinate{X: 1, Y: 1}}
+
+	tests := []struct {
+		name   string
+		room   Room
+		events []Event
+		opts   []Option
+		want_d string
+		want_c Coordinate
+		err    error
+	}{
+		{"Only created", room, []Event{create}, nil, "N", Coordinate{X: 1, Y: 1}, nil},
+		{"Commands", room, []Event{create, {Kind: EventCmd, Cmd: "RFF", Direction: "E", Coordinate: Coordinate{X: 2, Y: 1}}}, nil, "E", Coordinate{X: 2, Y: 1}, nil},
+		{"Stop policy", room, []Event{create, {Kind: EventCmd, Cmd: "FFL", Direction: "N", Coordinate: Coordinate{X: 1, Y: 0}}}, []Option{WithCollisionPolicy(Stop)}, "N", Coordinate{X: 1, Y: 0}, nil},
+		{"Charge", room, []Event{create, {Kind: EventCmd, Cmd: "FFF", Direction: "N", Coordinate: Coordinate{X: 1, Y: 0}}, {Kind: EventCharge, Direction: "N", Coordinate: Coordinate{X: 1, Y: 0}}, {Kind: EventCmd, Cmd: "RF", Direction: "E", Coordinate: Coordinate{X: 2, Y: 0}}}, []Option{WithBattery(2, EnergyCosts{F: 1})}, "E", Coordinate{X: 2, Y: 0}, nil},
+		{"Empty log", room, nil, nil, "", Coordinate{}, ErrInvalidLog},
+		{"No create event", room, []Event{{Kind: EventCmd, Cmd: "F"}}, nil, "", Coordinate{}, ErrInvalidLog},
+		{"Unknown event", room, []Event{create, {Kind: "jump"}}, nil, "", Coordinate{}, ErrInvalidLog},
+		{"Nothing to undo", room, []Event{create, {Kind: EventUndo}}, nil, "", Coordinate{}, ErrNothingToUndo},
+		{"Invalid start", Room{X: 1, Y: 1}, []Event{create}, nil, "", Coordinate{}, ErrOutsideRoom},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Replay(tt.room, tt.events, tt.opts...)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Got %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+
+			d, c := r.Report()
+			if d != tt.want_d || c != tt.want_c {
+				t.Errorf("Got %s %+v, want %s %+v", d, c, tt.want_d, tt.want_c)
+			}
+		})
+	}
+
+	// A different room makes the replayed state diverge from the log.
+	events := []Event{create, {Kind: EventCmd, Cmd: "RFF", Direction: "E", Coordinate: Coordinate{X: 2, Y: 1}}}
+	var mismatch ErrReplayMismatch
+	if _, err := Replay(Room{X: 5, Y: 5}, events); !errors.As(err, &mismatch) || mismatch.Index != 1 {
+		t.Errorf("Got %v, want %v", err, ErrReplayMismatch{Index: 1})
+	}
+}
@@ -0,0 +1,116 @@
+package robot
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	cellFree    = '.'
+	cellBlocked = '#'
+)
+
+/*
+GridMap is a per-cell free/blocked bitmap that can describe rooms of any shape, e.g. L-shaped rooms or rooms with holes.
+In JSON the map is represented as a list of rows where each rune is one cell, '.' for a free cell and '#' for a blocked cell.
+The first row is y = 0 and the first rune of each row is x = 0.
+*/
+type GridMap struct {
+	x, y    uint
+	blocked []uint64
+}
+
+// Creates a new grid map from a list of rows. All rows must have the same length and only contain '.' and '#'.
+func NewGridMap(rows []string) (*GridMap, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: the map must contain at least one row", ErrInvalidMap)
+	}
+
+	width := uint(len([]rune(rows[0])))
+	if width == 0 {
+		return nil, fmt.Errorf("%w: the map rows must not be empty", ErrInvalidMap)
+	}
+
+	m := &GridMap{x: width, y: uint(len(rows))}
+	m.blocked = make([]uint64, (m.x*m.y+63)/64)
+
+	for y, row := range rows {
+		cells := []rune(row)
+		if uint(len(cells)) != width {
+			return nil, fmt.Errorf("%w: row %d of the map has length %d, expected %d", ErrInvalidMap, y, len(cells), width)
+		}
+
+		for x, cell := range cells {
+			switch cell {
+			case cellFree:
+			case cellBlocked:
+				m.block(Coordinate{X: uint(x), Y: uint(y)})
+			default:
+				return nil, fmt.Errorf("%w: invalid cell %q at x: %d y: %d, expected '.' or '#'", ErrInvalidMap, cell, x, y)
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// Returns the width (x) and height (y) of the map.
+func (m *GridMap) Size() (uint, uint) {
+	return m.x, m.y
+}
+
+// Returns true if the coordinate is outside the map or the cell is blocked.
+func (m *GridMap) Blocked(c Coordinate) bool {
+	if c.X >= m.x || c.Y >= m.y {
+		return true
+	}
+
+	i := c.Y*m.x + c.X
+	return m.blocked[i/64]&(1<<(i%64)) != 0
+}
+
+// Marks the cell as blocked. The coordinate must be inside the map.
+func (m *GridMap) block(c Coordinate) {
+	i := c.Y*m.x + c.X
+	m.blocked[i/64] |= 1 << (i % 64)
+}
+
+// Returns the map as a list of rows using the same format that NewGridMap accepts.
+func (m *GridMap) Rows() []string {
+	rows := make([]string, m.y)
+	var sb strings.Builder
+
+	for y := uint(0); y < m.y; y++ {
+		sb.Reset()
+		for x := uint(0); x < m.x; x++ {
+			if m.Blocked(Coordinate{X: x, Y: y}) {
+				sb.WriteRune(cellBlocked)
+			} else {
+				sb.WriteRune(cellFree)
+			}
+		}
+		rows[y] = sb.String()
+	}
+
+	return rows
+}
+
+func (m *GridMap) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.Rows())
+}
+
+func (m *GridMap) UnmarshalJSON(b []byte) error {
+	var rows []string
+	if err := json.Unmarshal(b, &rows); err != nil {
+		return err
+	}
+
+	n, err := NewGridMap(rows)
+	if err != nil {
+		return err
+	}
+
+	*m = *n
+	return nil
+}
@@ -0,0 +1,94 @@
+package robot
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestNewGridMap(t *testing.T) {
+	tests := []struct {
+		name    string
+		rows    []string
+		wantErr bool
+	}{
+		{name: "Rectangular map", rows: []string{"...", "...", "..."}},
+		{name: "L-shaped map", rows: []string{"..#", "..#", "..."}},
+		{name: "No rows", rows: []string{}, wantErr: true},
+		{name: "Empty row", rows: []string{""}, wantErr: true},
+		{name: "Rows of different length", rows: []string{"...", ".."}, wantErr: true},
+		{name: "Invalid cell", rows: []string{"..x"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewGridMap(tt.rows)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewGridMap() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(m.Rows(), tt.rows) {
+				t.Errorf("Rows() = %v, want %v", m.Rows(), tt.rows)
+			}
+		})
+	}
+}
+
+func TestGridMapBlocked(t *testing.T) {
+	// A room with a hole in the middle and 70 cells to make sure the bitmap spans more than one word.
+	rows := []string{
+		"..........",
+		"..........",
+		"..........",
+		"....##....",
+		"..........",
+		"..........",
+		".........#",
+	}
+	m, err := NewGridMap(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		c    Coordinate
+		want bool
+	}{
+		{Coordinate{X: 0, Y: 0}, false},
+		{Coordinate{X: 4, Y: 3}, true},
+		{Coordinate{X: 5, Y: 3}, true},
+		{Coordinate{X: 6, Y: 3}, false},
+		{Coordinate{X: 9, Y: 6}, true},
+		{Coordinate{X: 8, Y: 6}, false},
+		{Coordinate{X: 10, Y: 0}, true},
+		{Coordinate{X: 0, Y: 7}, true},
+	}
+
+	for _, tt := range tests {
+		if got := m.Blocked(tt.c); got != tt.want {
+			t.Errorf("Blocked(%v) = %v, want %v", tt.c, got, tt.want)
+		}
+	}
+}
+
+func TestGridMapJSON(t *testing.T) {
+	r := Room{}
+	if err := json.Unmarshal([]byte(`{"map": ["..#", "..."]}`), &r); err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Map == nil || !r.Map.Blocked(Coordinate{X: 2, Y: 0}) || r.Map.Blocked(Coordinate{X: 2, Y: 1}) {
+		t.Fatalf("map was not decoded correctly: %v", r.Map)
+	}
+
+	b, err := json.Marshal(r.Map)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `["..#","..."]` {
+		t.Errorf("json.Marshal() = %s", b)
+	}
+
+	if err := json.Unmarshal([]byte(`{"map": ["..#", ".."]}`), &r); err == nil {
+		t.Error("expected an error for an invalid map")
+	}
+}
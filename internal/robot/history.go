@@ -0,0 +1,89 @@
+package robot
+
+// The number of command batches that can be undone.
+const maxHistory = 100
+
+// snapshot is the state of a robot before or after a command batch.
+type snapshot struct {
+	compass    Compass
+	coordinate Coordinate
+}
+
+// Records the current state of the robot before a command batch is executed. Anything that could be redone is forgotten.
+func (r *Robot) record() {
+	r.history = append(r.history, snapshot{compass: r.compass, coordinate: r.coordinate})
+	if len(r.history) > maxHistory {
+		r.history = r.history[len(r.history)-maxHistory:]
+	}
+	r.future = nil
+}
+
+// Restores the robot to the snapshot. In a shared room this fails if the cell has been taken by another robot since.
+func (r *Robot) restore(s snapshot) error {
+	if r.shared != nil && s.coordinate != r.coordinate && !r.shared.move(r, r.coordinate, s.coordinate) {
+		return ErrOccupied
+	}
+
+	r.compass, r.coordinate = s.compass, s.coordinate
+	return nil
+}
+
+// Undo restores the state the robot had before the latest command batch and returns the restored state.
+// Up to 100 command batches can be undone. The energy the batch used is not restored.
+func (r *Robot) Undo() (string, Coordinate, error) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	if r.busy() {
+		d, c := r.report()
+		return d, c, ErrBusy
+	}
+
+	if len(r.history) == 0 {
+		d, c := r.report()
+		return d, c, ErrNothingToUndo
+	}
+
+	current := snapshot{compass: r.compass, coordinate: r.coordinate}
+	if err := r.restore(r.history[len(r.history)-1]); err != nil {
+		d, c := r.report()
+		return d, c, err
+	}
+
+	r.history = r.history[:len(r.history)-1]
+	r.future = append(r.future, current)
+	r.emit(EventUndo, "")
+
+	d, c := r.report()
+	return d, c, nil
+}
+
+// Redo restores the state the robot had before the latest call to Undo and returns the restored state.
+// Executing a new command batch makes it impossible to redo anything that was undone before it.
+func (r *Robot) Redo() (string, Coordinate, error) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	if r.busy() {
+		d, c := r.report()
+		return d, c, ErrBusy
+	}
+
+	if len(r.future) == 0 {
+		d, c := r.report()
+		return d, c, ErrNothingToRedo
+	}
+
+	current := snapshot{compass: r.compass, coordinate: r.coordinate}
+	if err := r.restore(r.future[len(r.future)-1]); err != nil {
+		d, c := r.report()
+		return d, c, err
+	}
+
+	r.future = r.future[:len(r.future)-1]
+	r.history = append(r.history, current)
+	r.emit(EventRedo, "")
+
+	d, c := r.report()
+	return d, c, nil
+}
@@ -0,0 +1,95 @@
+package robot
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRobotUndoRedo(t *testing.T) {
+	r, err := NewRobot(Room{X: 5, Y: 5}, "N", Coordinate{X: 2, Y: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := r.Undo(); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("Got %v, want %v", err, ErrNothingToUndo)
+	}
+
+	r.Cmd("FF")
+	r.Cmd("RF")
+	// Command strings that can not be parsed are not recorded.
+	r.Cmd("X")
+
+	steps := []struct {
+		op     func() (string, Coordinate, error)
+		want_d string
+		want_c Coordinate
+		err    error
+	}{
+		{r.Undo, "N", Coordinate{X: 2, Y: 0}, nil},
+		{r.Undo, "N", Coordinate{X: 2, Y: 2}, nil},
+		{r.Undo, "N", Coordinate{X: 2, Y: 2}, ErrNothingToUndo},
+		{r.Redo, "N", Coordinate{X: 2, Y: 0}, nil},
+		{r.Redo, "E", Coordinate{X: 3, Y: 0}, nil},
+		{r.Redo, "E", Coordinate{X: 3, Y: 0}, ErrNothingToRedo},
+		{r.Undo, "N", Coordinate{X: 2, Y: 0}, nil},
+	}
+
+	for i, s := range steps {
+		d, c, err := s.op()
+		if d != s.want_d || c != s.want_c || !errors.Is(err, s.err) {
+			t.Errorf("step %d: Got %s %v %v, want %s %v %v", i, d, c, err, s.want_d, s.want_c, s.err)
+		}
+	}
+
+	// A new command batch clears what could be redone.
+	r.Cmd("L")
+	if _, _, err := r.Redo(); !errors.Is(err, ErrNothingToRedo) {
+		t.Errorf("Got %v, want %v", err, ErrNothingToRedo)
+	}
+}
+
+func TestRobotUndoBounded(t *testing.T) {
+	r, err := NewRobot(Room{X: 5, Y: 5}, "N", Coordinate{X: 2, Y: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < maxHistory+10; i++ {
+		r.Cmd("R")
+	}
+
+	undone := 0
+	for ; undone < maxHistory+10; undone++ {
+		if _, _, err := r.Undo(); err != nil {
+			break
+		}
+	}
+
+	if undone != maxHistory {
+		t.Errorf("Undid %d batches, want %d", undone, maxHistory)
+	}
+}
+
+func TestRobotUndoSharedRoom(t *testing.T) {
+	sr, err := NewSharedRoom(Room{X: 3, Y: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, _ := sr.NewRobot("E", Coordinate{X: 0, Y: 0})
+	b, _ := sr.NewRobot("N", Coordinate{X: 0, Y: 1})
+
+	a.Cmd("F")
+	b.Cmd("F")
+
+	// The cell a came from is now occupied by b.
+	if _, c, err := a.Undo(); !errors.Is(err, ErrOccupied) || c != (Coordinate{X: 1, Y: 0}) {
+		t.Errorf("Got %v %v, want %v", c, err, ErrOccupied)
+	}
+
+	b.Undo()
+	if _, c, err := a.Undo(); err != nil || c != (Coordinate{X: 0, Y: 0}) {
+		t.Errorf("Got %v %v", c, err)
+	}
+}
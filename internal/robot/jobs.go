@@ -0,0 +1,198 @@
+package robot
+
+import (
+	"time"
+)
+
+// Timing is the simulated time each command takes when a command string is executed with Submit. B, < and > take the same time as F.
+type Timing struct {
+	Move time.Duration
+	Turn time.Duration
+}
+
+// Makes the robot execute command strings submitted with Submit in simulated time.
+func WithTiming(t Timing) Option {
+	return func(r *Robot) {
+		r.timing = &t
+	}
+}
+
+// The number of finished jobs that are kept, see Robot.Finished.
+const maxFinishedJobs = 10
+
+// job is a command string that has been submitted for execution in simulated time.
+type job struct {
+	id       uint64
+	cmd      string
+	prog     []instruction
+	steps    uint64
+	done     uint64
+	finished bool
+	end      Pose
+	err      error
+}
+
+/*
+JobStatus describes a submitted command string. Done is the number of steps that have been executed out of Steps.
+Once the job has finished, Finished is true, End is the state of the robot after the job and Err the error that aborted the job, if any, e.g. an ErrCollision with the index of the step in the job.
+*/
+type JobStatus struct {
+	Id       uint64
+	Cmd      string
+	Steps    uint64
+	Done     uint64
+	Finished bool
+	End      Pose
+	Err      error
+}
+
+func (j *job) status() JobStatus {
+	return JobStatus{Id: j.id, Cmd: j.cmd, Steps: j.steps, Done: j.done, Finished: j.finished, End: j.end, Err: j.err}
+}
+
+// Returns true if the robot executes command strings submitted with Submit in simulated time.
+func (r *Robot) Timed() bool {
+	r.l.RLock()
+	defer r.l.RUnlock()
+
+	return r.timing != nil
+}
+
+/*
+Submit queues a command string for execution in simulated time and returns immediately. The command string is parsed before it is queued, so errors in it are returned right away.
+The queued command strings are executed one at a time, in the order they were submitted, by a goroutine that runs as long as the queue is not empty.
+Each step takes the time set with WithTiming and the robot is only locked while a step is executed, so Report returns the state of the robot in the middle of a command string.
+The execution of a queued command string is aborted by the same errors as Cmd, after which the next command string in the queue is executed. The outcome of the latest finished command strings is returned by Finished. While the queue is not empty, Cmd, Goto, Undo and Redo return ErrBusy, and so does Submit while a command string is streamed to the robot.
+Returns ErrNotTimed if the robot was not created with WithTiming.
+*/
+func (r *Robot) Submit(cs string) (JobStatus, error) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	if r.timing == nil {
+		return JobStatus{}, ErrNotTimed
+	}
+	if r.streaming {
+		return JobStatus{}, ErrBusy
+	}
+
+	prog, err := parse(cs)
+	if err != nil {
+		return JobStatus{}, err
+	}
+
+	return r.enqueue(cs, prog), nil
+}
+
+/*
+SubmitGoto plans a route to the target like Plan and queues it like Submit. Since the route is planned from the current state of the robot, ErrBusy is returned if there already are queued command strings, which would move the robot before the route is executed.
+The plan is returned together with the queued job. If the robot already is at the target the plan is empty and nothing is queued.
+*/
+func (r *Robot) SubmitGoto(target Coordinate, fewestTurns bool) (string, JobStatus, error) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	if r.timing == nil {
+		return "", JobStatus{}, ErrNotTimed
+	}
+	if r.busy() {
+		return "", JobStatus{}, ErrBusy
+	}
+
+	cs, err := r.clone().plan(target, fewestTurns)
+	if err != nil || cs == "" {
+		return cs, JobStatus{}, err
+	}
+
+	prog, err := parse(cs)
+	if err != nil {
+		return "", JobStatus{}, err
+	}
+
+	return cs, r.enqueue(cs, prog), nil
+}
+
+// Queues a parsed command string and starts executing the queue if it was empty. The caller must hold the exclusive lock.
+func (r *Robot) enqueue(cs string, prog []instruction) JobStatus {
+	r.jobId++
+	j := &job{id: r.jobId, cmd: cs, prog: prog, steps: steps(prog)}
+	r.jobs = append(r.jobs, j)
+
+	if len(r.jobs) == 1 {
+		go r.work()
+	}
+
+	return j.status()
+}
+
+// Returns the command strings that are queued for execution, starting with the one that is being executed.
+func (r *Robot) Queue() []JobStatus {
+	r.l.RLock()
+	defer r.l.RUnlock()
+
+	q := make([]JobStatus, len(r.jobs))
+	for i, j := range r.jobs {
+		q[i] = j.status()
+	}
+
+	return q
+}
+
+// Returns the latest jobs that have finished, oldest first. At most the last 10 finished jobs are kept.
+func (r *Robot) Finished() []JobStatus {
+	r.l.RLock()
+	defer r.l.RUnlock()
+
+	f := make([]JobStatus, len(r.finished))
+	for i, j := range r.finished {
+		f[i] = j.status()
+	}
+
+	return f
+}
+
+// Returns true if there are queued command strings or a command string is being streamed to the robot. The caller must hold the lock.
+func (r *Robot) busy() bool {
+	return len(r.jobs) > 0 || r.streaming
+}
+
+// Executes queued command strings until the queue is empty.
+func (r *Robot) work() {
+	for {
+		r.l.Lock()
+		if len(r.jobs) == 0 {
+			r.l.Unlock()
+			return
+		}
+		j, timing := r.jobs[0], *r.timing
+		r.record()
+		r.l.Unlock()
+
+		res := Result{}
+		err := walk(j.prog, func(c rune) error {
+			d := timing.Move
+			if c == 'L' || c == 'R' {
+				d = timing.Turn
+			}
+			time.Sleep(d)
+
+			r.l.Lock()
+			defer r.l.Unlock()
+
+			err := r.exec(c, cmdConfig{}, &res)
+			j.done = uint64(res.Steps)
+			return err
+		})
+
+		r.l.Lock()
+		r.emit(EventCmd, j.cmd)
+		r.jobs = r.jobs[1:]
+
+		j.finished, j.err = true, err
+		j.end.Direction, j.end.Coordinate = r.report()
+		if r.finished = append(r.finished, j); len(r.finished) > maxFinishedJobs {
+			r.finished = r.finished[1:]
+		}
+		r.l.Unlock()
+	}
+}
@@ -0,0 +1,162 @@
+package robot
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRobotSubmit(t *testing.T) {
+	var events []Event
+	r, err := NewRobot(Room{X: 5, Y: 5}, "N", Coordinate{X: 0, Y: 4}, WithTiming(Timing{Move: 20 * time.Millisecond, Turn: 10 * time.Millisecond}), WithEventSink(func(e Event) {
+		events = append(events, e)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Submit("FX"); err != (ErrInvalidCommand{Index: 1, Rune: 'X'}) {
+		t.Errorf("Got %v, want an invalid command", err)
+	}
+
+	first, err := r.Submit("2F")
+	if err != nil || first != (JobStatus{Id: 1, Cmd: "2F", Steps: 2}) {
+		t.Errorf("Got %+v %v", first, err)
+	}
+	second, err := r.Submit("R3[F]")
+	if err != nil || second != (JobStatus{Id: 2, Cmd: "R3[F]", Steps: 4}) {
+		t.Errorf("Got %+v %v", second, err)
+	}
+
+	if q := r.Queue(); len(q) != 2 || q[0].Id != 1 || q[1].Id != 2 {
+		t.Errorf("Got queue %+v", q)
+	}
+
+	// The robot can not be commanded directly while it is busy.
+	if _, err := r.Cmd("F"); !errors.Is(err, ErrBusy) {
+		t.Errorf("Got %v, want %v", err, ErrBusy)
+	}
+	if _, _, err := r.Undo(); !errors.Is(err, ErrBusy) {
+		t.Errorf("Got %v, want %v", err, ErrBusy)
+	}
+
+	// The robot is seen in the middle of the command strings.
+	mid := false
+	deadline := time.Now().Add(5 * time.Second)
+	for len(r.Queue()) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the queue was not emptied in time")
+		}
+		if _, c := r.Report(); c != (Coordinate{X: 0, Y: 4}) && c != (Coordinate{X: 3, Y: 2}) {
+			mid = true
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if !mid {
+		t.Error("The robot was never seen in the middle of the command strings")
+	}
+	if d, c := r.Report(); d != "E" || c != (Coordinate{X: 3, Y: 2}) {
+		t.Errorf("Got %s %v, want E {3 2}", d, c)
+	}
+
+	// The command strings are recorded like any other.
+	if len(events) != 3 || events[1].Cmd != "2F" || events[2].Cmd != "R3[F]" {
+		t.Errorf("Got events %+v", events)
+	}
+	if d, c, err := r.Undo(); err != nil || d != "N" || c != (Coordinate{X: 0, Y: 2}) {
+		t.Errorf("Got %s %v %v", d, c, err)
+	}
+
+	// Once the queue is empty the robot can be commanded directly again.
+	if res, err := r.Cmd("F"); err != nil || res.Coordinate != (Coordinate{X: 0, Y: 1}) {
+		t.Errorf("Got %+v %v", res, err)
+	}
+}
+
+func TestRobotSubmitNotTimed(t *testing.T) {
+	r, err := NewRobot(Room{X: 5, Y: 5}, "N", Coordinate{X: 0, Y: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Submit("F"); !errors.Is(err, ErrNotTimed) {
+		t.Errorf("Got %v, want %v", err, ErrNotTimed)
+	}
+}
+
+func TestRobotSubmitGoto(t *testing.T) {
+	r, err := NewRobot(Room{X: 5, Y: 5}, "E", Coordinate{X: 0, Y: 0}, WithTiming(Timing{Move: time.Millisecond, Turn: time.Millisecond}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Submit("4F"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The route would be planned from a state the robot has left before it is executed.
+	if _, _, err := r.SubmitGoto(Coordinate{X: 2, Y: 0}, false); !errors.Is(err, ErrBusy) {
+		t.Errorf("Got %v, want %v", err, ErrBusy)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(r.Queue()) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the queue was not emptied in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	plan, job, err := r.SubmitGoto(Coordinate{X: 2, Y: 0}, false)
+	if err != nil || plan != "LLFF" || job != (JobStatus{Id: 2, Cmd: "LLFF", Steps: 4}) {
+		t.Errorf("Got %q %+v %v", plan, job, err)
+	}
+}
+
+func TestRobotFinished(t *testing.T) {
+	r, err := NewRobot(Room{X: 5, Y: 5}, "N", Coordinate{X: 0, Y: 0}, WithCollisionPolicy(Stop), WithTiming(Timing{Move: time.Millisecond, Turn: time.Millisecond}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, cs := range []string{"FRF", "RF"} {
+		if _, err := r.Submit(cs); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(r.Queue()) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the queue was not emptied in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// The job that was aborted keeps its error, so it does not vanish without a trace.
+	want := []JobStatus{
+		{Id: 1, Cmd: "FRF", Steps: 3, Done: 1, Finished: true, End: Pose{"N", Coordinate{X: 0, Y: 0}}, Err: ErrCollision{Step: 0}},
+		{Id: 2, Cmd: "RF", Steps: 2, Done: 2, Finished: true, End: Pose{"E", Coordinate{X: 1, Y: 0}}},
+	}
+	if got := r.Finished(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %+v, want %+v", got, want)
+	}
+
+	for i := 0; i < maxFinishedJobs; i++ {
+		if _, err := r.Submit("L"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for len(r.Queue()) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the queue was not emptied in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if f := r.Finished(); len(f) != maxFinishedJobs || f[0].Id != 3 {
+		t.Errorf("Got %+v, want the last %d jobs", f, maxFinishedJobs)
+	}
+}
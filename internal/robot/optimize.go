@@ -0,0 +1,279 @@
+package robot
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Optimization is an optimized command string. Steps is the number of steps in the original command string and OptimizedSteps the number of steps in Cmds.
+type Optimization struct {
+	Cmds           string
+	Steps          uint64
+	OptimizedSteps uint64
+}
+
+// Returns the number of steps that were saved by the optimization.
+func (o Optimization) Saved() uint64 {
+	return o.Steps - o.OptimizedSteps
+}
+
+/*
+Optimize rewrites a command string into a shorter command string that brings a robot with the given compass mode to the same state from any starting pose.
+Every series of turns is replaced with the fewest turns that face the same direction, e.g. "LLLL" and "LR" are removed and "RRR" becomes "L" with a four-point compass, and runs of the same move are joined, e.g. "FLRF" becomes "FF".
+Moves are kept as they are, since a move that collides from one starting pose does not from another, see Robot.Optimize.
+Groups are optimized on their own and kept, e.g. "1000[FLR]" becomes "1000[F]" and then "1000F", unless they only turn or are not longer when written out. The result uses counts for runs of three or more of the same command, e.g. "3F", and is never longer than the original command string.
+*/
+func Optimize(cs string, m CompassMode) (Optimization, error) {
+	prog, err := parse(cs)
+	if err != nil {
+		return Optimization{}, err
+	}
+
+	n := steps(prog)
+	opt := optimize(prog, m)
+
+	res := Optimization{Cmds: encodeParts(opt), Steps: n, OptimizedSteps: partSteps(opt)}
+	if len(res.Cmds) > len(cs) {
+		return Optimization{Cmds: cs, Steps: n, OptimizedSteps: n}, nil
+	}
+	return res, nil
+}
+
+/*
+Optimize rewrites a command string into a shorter command string that brings the robot to the same state from its current pose, see the package level Optimize.
+Since the starting pose is known, moves that would collide are removed as well, except with the Stop policy where everything after the first collision is removed instead, as it would never be executed.
+The moves are predicted on a copy of the robot, so in a shared room the other robots are treated as obstacles that stay where they are, see Simulate.
+Since which moves collide can change with every repeat, repeats and groups are written out before the command string is optimized. If that makes the result longer than the original command string, the original is returned.
+*/
+func (r *Robot) Optimize(cs string) (Optimization, error) {
+	prog, err := parse(cs)
+	if err != nil {
+		return Optimization{}, err
+	}
+
+	n := steps(prog)
+
+	r.l.RLock()
+	sim := r.clone()
+	r.l.RUnlock()
+
+	// The walk is stopped at the first collision with the Stop policy.
+	flat := make([]rune, 0, n)
+	walk(prog, func(c rune) error {
+		bumped, err := sim.doCmd(c)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case !bumped:
+			flat = append(flat, c)
+		case sim.policy == Stop:
+			flat = append(flat, c)
+			return ErrCollision{Step: len(flat) - 1}
+		}
+		return nil
+	})
+
+	opt := mergeTurns(flat, sim.compass.mode)
+	res := Optimization{Cmds: encode(opt), Steps: n, OptimizedSteps: uint64(len(opt))}
+	if len(res.Cmds) > len(cs) {
+		return Optimization{Cmds: cs, Steps: n, OptimizedSteps: n}, nil
+	}
+	return res, nil
+}
+
+// Replaces every series of turns with the fewest turns that face the same direction. If a half turn is needed it is made in the same direction as the last turn of the series.
+func mergeTurns(cmds []rune, m CompassMode) []rune {
+	points := len(directions) / int(m.step())
+	var res []rune
+	net := 0
+	var last rune
+
+	flush := func() {
+		net = (net%points + points) % points
+		switch {
+		case net == 0:
+		case net*2 == points:
+			res = append(res, []rune(strings.Repeat(string(last), net))...)
+		case net*2 < points:
+			res = append(res, []rune(strings.Repeat("R", net))...)
+		default:
+			res = append(res, []rune(strings.Repeat("L", points-net))...)
+		}
+		net = 0
+	}
+
+	for _, c := range cmds {
+		switch c {
+		case 'R':
+			net++
+		case 'L':
+			net--
+		default:
+			flush()
+			res = append(res, c)
+			continue
+		}
+		last = c
+	}
+	flush()
+
+	return res
+}
+
+// Encodes commands as a command string, using counts for runs of three or more of the same command.
+func encode(cmds []rune) string {
+	var sb strings.Builder
+
+	for i := 0; i < len(cmds); {
+		j := i
+		for j < len(cmds) && cmds[j] == cmds[i] {
+			j++
+		}
+
+		if n := j - i; n >= 3 {
+			sb.WriteString(strconv.Itoa(n))
+			sb.WriteRune(cmds[i])
+		} else {
+			sb.WriteString(strings.Repeat(string(cmds[i]), n))
+		}
+		i = j
+	}
+
+	return sb.String()
+}
+
+// part is a run of n of the same command, or a group that is repeated n times if group is not nil.
+type part struct {
+	cmd   rune
+	n     uint64
+	group []part
+}
+
+func isTurn(c rune) bool {
+	return c == 'L' || c == 'R'
+}
+
+// Optimizes the instructions of a program, see Optimize. A group is inlined if it only turns or is not longer written out.
+func optimize(prog []instruction, m CompassMode) []part {
+	var res []part
+
+	for _, ins := range prog {
+		if ins.group == nil {
+			res = append(res, part{cmd: ins.cmd, n: uint64(ins.n)})
+			continue
+		}
+
+		body := optimize(ins.group, m)
+		n := uint64(ins.n)
+		turns := true
+		for _, p := range body {
+			turns = turns && p.group == nil && isTurn(p.cmd)
+		}
+
+		switch {
+		case turns:
+			// The group is a single turn at most, so repeating it only multiplies the turn.
+			for _, p := range body {
+				res = append(res, part{cmd: p.cmd, n: p.n * n})
+			}
+		case n == 1:
+			res = append(res, body...)
+		case len(body) == 1 && body[0].group == nil:
+			res = append(res, part{cmd: body[0].cmd, n: body[0].n * n})
+		default:
+			l := uint64(len(encodeParts(body)))
+			if n*l <= uint64(len(strconv.FormatUint(n, 10)))+2+l {
+				for i := uint64(0); i < n; i++ {
+					res = append(res, body...)
+				}
+			} else {
+				res = append(res, part{n: n, group: body})
+			}
+		}
+	}
+
+	return mergeParts(res, m)
+}
+
+// Joins runs of the same move and replaces every series of turns with the fewest turns that face the same direction, see mergeTurns.
+func mergeParts(parts []part, m CompassMode) []part {
+	points := uint64(len(directions)) / uint64(m.step())
+	var res []part
+	var net uint64
+	var last rune
+
+	flush := func() {
+		net %= points
+		switch {
+		case net == 0:
+		case net*2 == points && last == 'L':
+			res = append(res, part{cmd: 'L', n: net})
+		case net*2 <= points:
+			res = append(res, part{cmd: 'R', n: net})
+		default:
+			res = append(res, part{cmd: 'L', n: points - net})
+		}
+		net = 0
+	}
+
+	for _, p := range parts {
+		switch {
+		case p.n == 0:
+		case p.group == nil && p.cmd == 'R':
+			net += p.n % points
+			last = p.cmd
+		case p.group == nil && p.cmd == 'L':
+			net += points - p.n%points
+			last = p.cmd
+		default:
+			flush()
+			if k := len(res) - 1; p.group == nil && k >= 0 && res[k].group == nil && res[k].cmd == p.cmd {
+				res[k].n += p.n
+			} else {
+				res = append(res, p)
+			}
+		}
+	}
+	flush()
+
+	return res
+}
+
+// Returns the number of steps in the optimized parts.
+func partSteps(parts []part) uint64 {
+	var n uint64
+	for _, p := range parts {
+		if p.group != nil {
+			n += p.n * partSteps(p.group)
+		} else {
+			n += p.n
+		}
+	}
+	return n
+}
+
+// Encodes optimized parts as a command string, using counts for runs of three or more of the same command and for repeated groups.
+func encodeParts(parts []part) string {
+	var sb strings.Builder
+	writeParts(&sb, parts)
+	return sb.String()
+}
+
+func writeParts(sb *strings.Builder, parts []part) {
+	for _, p := range parts {
+		switch {
+		case p.group != nil:
+			sb.WriteString(strconv.FormatUint(p.n, 10))
+			sb.WriteByte('[')
+			writeParts(sb, p.group)
+			sb.WriteByte(']')
+		case p.n >= 3:
+			sb.WriteString(strconv.FormatUint(p.n, 10))
+			sb.WriteRune(p.cmd)
+		default:
+			sb.WriteString(strings.Repeat(string(p.cmd), int(p.n)))
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package robot
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		cs      string
+		m       CompassMode
+		want    string
+		saved   uint64
+		wantErr error
+	}{
+		{"", FourPoint, "", 0, nil},
+		{"LLLL", FourPoint, "", 4, nil},
+		{"LR", FourPoint, "", 2, nil},
+		{"RRR", FourPoint, "L", 2, nil},
+		{"RRR", EightPoint, "3R", 0, nil},
+		{"LL", FourPoint, "LL", 0, nil},
+		{"RLRR", FourPoint, "RR", 2, nil},
+		{"FLRFRRRRBLLL<", FourPoint, "FFBR<", 8, nil},
+		{"FFLRFRRRR", FourPoint, "3F", 6, nil},
+		{"5[LR]F", FourPoint, "F", 10, nil},
+		{"3[FFR]", FourPoint, "3[FFR]", 0, nil},
+		{"2[FR]", FourPoint, "FRFR", 0, nil},
+		{"1000[FLR]", FourPoint, "1000F", 2000, nil},
+		{"4[F2[LR]B]", FourPoint, "4[FB]", 16, nil},
+		{"3[R]2[RF]", EightPoint, "4RFRF", 0, nil},
+		{"2[FFR]2[RRF]", FourPoint, "FFRFFLFRRF", 2, nil},
+		{"FX", FourPoint, "", 0, ErrInvalidCommand{Index: 1, Rune: 'X'}},
+		{"9999[9999F]", FourPoint, "", 0, ErrTooManySteps},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("Test optimize: %q %v", tt.cs, tt.m), func(t *testing.T) {
+			got, err := Optimize(tt.cs, tt.m)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Got error %v, want %v", err, tt.wantErr)
+			}
+			if got.Cmds != tt.want || got.Saved() != tt.saved {
+				t.Errorf("Got %+v saved %d, want %q saved %d", got, got.Saved(), tt.want, tt.saved)
+			}
+		})
+	}
+}
+
+func TestOptimizeLength(t *testing.T) {
+	room := Room{X: 100, Y: 100}
+
+	for _, cs := range []string{"1000[FL]", "2[FRFLB]", "10[2[FR]L]", "FRFLFRFL", "3[FF]LL2[R]"} {
+		t.Run(fmt.Sprintf("Test optimize length: %q", cs), func(t *testing.T) {
+			r, err := NewRobot(room, "N", Coordinate{X: 50, Y: 50})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Optimize(cs, FourPoint)
+			if err != nil || len(got.Cmds) > len(cs) {
+				t.Errorf("Got %+v %v, want at most %d commands", got, err, len(cs))
+			}
+
+			// The robot ends up in the same state either way.
+			sim, _ := r.Simulate(cs)
+			opt, _ := r.Simulate(got.Cmds)
+			if sim.Direction != opt.Direction || sim.Coordinate != opt.Coordinate {
+				t.Errorf("Got %s %v, want %s %v", opt.Direction, opt.Coordinate, sim.Direction, sim.Coordinate)
+			}
+
+			got, err = r.Optimize(cs)
+			if err != nil || len(got.Cmds) > len(cs) {
+				t.Errorf("Got robot %+v %v, want at most %d commands", got, err, len(cs))
+			}
+		})
+	}
+}
+
+func TestRobotOptimize(t *testing.T) {
+	room := Room{X: 3, Y: 3, Obstacles: []Coordinate{{X: 1, Y: 1}}}
+
+	tests := []struct {
+		cs     string
+		policy CollisionPolicy
+		want   string
+	}{
+		// The moves into the wall and the obstacle are removed and the turns around them merged.
+		{"FFLFRFRR", Clamp, "FFRR"},
+		{"RFLFRF", Clamp, "RFF"},
+		{"RFLFRF", Wrap, "RFF"},
+		{"FFFFR", Wrap, "4FR"},
+		// Nothing after the first collision is executed with the Stop policy.
+		{"FFFRF", Stop, "3F"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("Test robot optimize: %q %v", tt.cs, tt.policy), func(t *testing.T) {
+			r, err := NewRobot(room, "N", Coordinate{X: 0, Y: 2}, WithCollisionPolicy(tt.policy))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := r.Optimize(tt.cs)
+			if err != nil || got.Cmds != tt.want {
+				t.Errorf("Got %+v %v, want %q", got, err, tt.want)
+			}
+
+			// The robot ends up in the same state either way.
+			sim, _ := r.Simulate(tt.cs)
+			opt, _ := r.Simulate(got.Cmds)
+			if sim.Direction != opt.Direction || sim.Coordinate != opt.Coordinate {
+				t.Errorf("Got %s %v, want %s %v", opt.Direction, opt.Coordinate, sim.Direction, sim.Coordinate)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package robot
+
+import (
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// All valid commands, see doCmd for what they do.
+const commands = "LRFB<>"
+
+// The most steps a command string can have after repeats and groups have been expanded.
+const maxSteps = 10000000
+
+/*
+Command strings are parsed according to the following grammar before they are executed:
+
+	program     = { instruction } ;
+	instruction = [ count ] ( command | "[" program "]" ) ;
+	count       = digit { digit } ;
+	command     = "L" | "R" | "F" | "B" | "<" | ">" ;
+
+Commands are case-insensitive. A count repeats the command or group that follows it, e.g. "10F" or "3[FFR]".
+Groups must not be empty and the command string must not have more than 10000000 steps after repeats and groups have been expanded, otherwise ErrTooManySteps is returned.
+*/
+
+// instruction is either a single command or a group of instructions, repeated n times.
+type instruction struct {
+	// The command to execute or 0 if the instruction is a group.
+	cmd   rune
+	group []instruction
+	n     uint
+	// Position of the first rune of the instruction in the command string.
+	pos int
+}
+
+type parser struct {
+	src io.RuneScanner
+	pos int
+}
+
+// Parses a complete command string.
+func parse(cs string) ([]instruction, error) {
+	p := &parser{src: strings.NewReader(cs)}
+	return p.program(false)
+}
+
+func (p *parser) read() (rune, error) {
+	c, _, err := p.src.ReadRune()
+	if err == nil {
+		p.pos++
+	}
+	return c, err
+}
+
+func (p *parser) unread() {
+	p.src.UnreadRune()
+	p.pos--
+}
+
+// Parses instructions until the end of the input or, if nested is true, until the closing bracket of the group.
+func (p *parser) program(nested bool) ([]instruction, error) {
+	var prog []instruction
+	var n uint64
+
+	for {
+		c, err := p.read()
+		if err == io.EOF {
+			if nested {
+				return nil, ErrSyntax{Index: p.pos, Msg: "missing ']'"}
+			}
+			return prog, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if c == ']' && nested {
+			return prog, nil
+		}
+
+		p.unread()
+		ins, err := p.instruction()
+		if err != nil {
+			return nil, err
+		}
+		prog = append(prog, ins)
+
+		if n += steps([]instruction{ins}); n > maxSteps {
+			return nil, ErrTooManySteps
+		}
+	}
+}
+
+// Parses the next instruction. Returns io.EOF if there is no more input.
+func (p *parser) instruction() (instruction, error) {
+	ins := instruction{n: 1, pos: p.pos}
+
+	c, err := p.read()
+	if err != nil {
+		return ins, err
+	}
+
+	if isDigit(c) {
+		p.unread()
+		if ins.n, err = p.count(); err != nil {
+			return ins, err
+		}
+
+		if c, err = p.read(); err == io.EOF {
+			return ins, ErrSyntax{Index: ins.pos, Msg: "count not followed by a command"}
+		}
+		if err != nil {
+			return ins, err
+		}
+	}
+
+	c = unicode.ToUpper(c)
+	switch {
+	case strings.ContainsRune(commands, c):
+		ins.cmd = c
+	case c == '[':
+		if ins.group, err = p.program(true); err != nil {
+			return ins, err
+		}
+		if len(ins.group) == 0 {
+			return ins, ErrSyntax{Index: ins.pos, Msg: "empty group"}
+		}
+	default:
+		return ins, ErrInvalidCommand{Index: p.pos - 1, Rune: c}
+	}
+
+	// The steps of a group are at most maxSteps, so this can not overflow.
+	if steps([]instruction{ins}) > maxSteps {
+		return ins, ErrTooManySteps
+	}
+
+	return ins, nil
+}
+
+// Parses a repeat count.
+func (p *parser) count() (uint, error) {
+	start := p.pos
+	var n uint64
+
+	for {
+		c, err := p.read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		if !isDigit(c) {
+			p.unread()
+			break
+		}
+
+		n = n*10 + uint64(c-'0')
+		if n > math.MaxUint32 {
+			return 0, ErrSyntax{Index: start, Msg: "count too large"}
+		}
+	}
+
+	if n == 0 {
+		return 0, ErrSyntax{Index: start, Msg: "count must be at least 1"}
+	}
+
+	return uint(n), nil
+}
+
+func isDigit(c rune) bool {
+	return c >= '0' && c <= '9'
+}
+
+// Calls fn for each command in the program, repeating commands and groups according to their counts.
+// The walk stops at the first error returned by fn.
+func walk(prog []instruction, fn func(c rune) error) error {
+	for _, ins := range prog {
+		for i := uint(0); i < ins.n; i++ {
+			var err error
+			if ins.group != nil {
+				err = walk(ins.group, fn)
+			} else if ins.cmd != 0 {
+				err = fn(ins.cmd)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Returns the number of steps in the program after repeats and groups have been expanded.
+func steps(prog []instruction) uint64 {
+	var n uint64
+	for _, ins := range prog {
+		if ins.group != nil {
+			n += uint64(ins.n) * steps(ins.group)
+		} else if ins.cmd != 0 {
+			n += uint64(ins.n)
+		}
+	}
+
+	return n
+}
+
+// Returns the first n steps of the program as a command string. Repeats and groups are kept where possible instead of being expanded, so the command string is about as long as the program.
+func prefix(prog []instruction, n int) string {
+	var sb strings.Builder
+	writePrefix(&sb, prog, uint64(n))
+	return sb.String()
+}
+
+func writePrefix(sb *strings.Builder, prog []instruction, n uint64) {
+	for _, ins := range prog {
+		if n == 0 {
+			return
+		}
+
+		all := steps([]instruction{ins})
+		if all <= n {
+			writeInstruction(sb, ins)
+			n -= all
+			continue
+		}
+
+		// The instruction is cut off, so it is written as the complete repetitions followed by the first steps of the next one.
+		one := all / uint64(ins.n)
+		if k := n / one; k > 0 {
+			ins.n = uint(k)
+			writeInstruction(sb, ins)
+		}
+		writePrefix(sb, ins.group, n%one)
+		return
+	}
+}
+
+// Writes the instruction as a command string. A group that is not repeated is written without brackets.
+func writeInstruction(sb *strings.Builder, ins instruction) {
+	if ins.n > 1 {
+		sb.WriteString(strconv.FormatUint(uint64(ins.n), 10))
+	}
+
+	switch {
+	case ins.group == nil:
+		sb.WriteRune(ins.cmd)
+	case ins.n > 1:
+		sb.WriteByte('[')
+		writePrefix(sb, ins.group, math.MaxUint64)
+		sb.WriteByte(']')
+	default:
+		writePrefix(sb, ins.group, math.MaxUint64)
+	}
+}
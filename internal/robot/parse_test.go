@@ -0,0 +1,123 @@
+package robot
+
+import (
+	"strings"
+	"testing"
+)
+
+// Expands a command string into the flat list of commands it represents.
+func expand(t *testing.T, cs string) (string, error) {
+	t.Helper()
+
+	prog, err := parse(cs)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	walk(prog, func(c rune) error {
+		sb.WriteRune(c)
+		return nil
+	})
+	return sb.String(), nil
+}
+
+func TestParseErrorMessages(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{ErrInvalidCommand{Index: 2, Rune: 'A'}, "invalid command 'A' at position 2, valid commands are L, R, F, B, <, >"},
+		{ErrSyntax{Index: 4, Msg: "missing ']'"}, "missing ']' at position 4"},
+	}
+
+	for _, tt := range tests {
+		if tt.err.Error() != tt.want {
+			t.Errorf("Error() = %s, want %s", tt.err.Error(), tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		cmd     string
+		want    string
+		wantErr error
+	}{
+		{cmd: "", want: ""},
+		{cmd: "LRF", want: "LRF"},
+		{cmd: "lrf", want: "LRF"},
+		{cmd: "10F", want: "FFFFFFFFFF"},
+		{cmd: "2L3R", want: "LLRRR"},
+		{cmd: "3[FFR]", want: "FFRFFRFFR"},
+		{cmd: "2[F2[LR]]F", want: "FLRLRFLRLRF"},
+		{cmd: "[F]", want: "F"},
+		{cmd: "fb<>", want: "FB<>"},
+		{cmd: "3>2<", want: ">>><<"},
+		{cmd: "FFA", wantErr: ErrInvalidCommand{Index: 2, Rune: 'A'}},
+		{cmd: "F]", wantErr: ErrInvalidCommand{Index: 1, Rune: ']'}},
+		{cmd: "2[FF", wantErr: ErrSyntax{Index: 4, Msg: "missing ']'"}},
+		{cmd: "F10", wantErr: ErrSyntax{Index: 1, Msg: "count not followed by a command"}},
+		{cmd: "0F", wantErr: ErrSyntax{Index: 0, Msg: "count must be at least 1"}},
+		{cmd: "99999999999F", wantErr: ErrSyntax{Index: 0, Msg: "count too large"}},
+		{cmd: "2[]F", wantErr: ErrSyntax{Index: 0, Msg: "empty group"}},
+		{cmd: "F[]", wantErr: ErrSyntax{Index: 1, Msg: "empty group"}},
+		{cmd: "4294967295[4294967295[]]", wantErr: ErrSyntax{Index: 11, Msg: "empty group"}},
+		{cmd: "4294967295[4294967295[F]]", wantErr: ErrTooManySteps},
+		{cmd: "10000001F", wantErr: ErrTooManySteps},
+		{cmd: "5000000F5000001F", wantErr: ErrTooManySteps},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cmd, func(t *testing.T) {
+			got, err := expand(t, tt.cmd)
+
+			if tt.wantErr != nil {
+				if err != tt.wantErr {
+					t.Errorf("parse(%q) err = %v, want %s", tt.cmd, err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil || got != tt.want {
+				t.Errorf("parse(%q) = %s %v, want %s", tt.cmd, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		n    int
+		want string
+	}{
+		{cmd: "FFR", n: 0, want: ""},
+		{cmd: "FFR", n: 2, want: "FF"},
+		{cmd: "10F", n: 7, want: "7F"},
+		{cmd: "2[3FR]", n: 5, want: "3FRF"},
+		{cmd: "3[F2[LR]]L", n: 13, want: "2[F2[LR]]FLR"},
+		{cmd: "3[F2[LR]]L", n: 16, want: "3[F2[LR]]L"},
+		{cmd: "10000000F", n: 9999999, want: "9999999F"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cmd, func(t *testing.T) {
+			prog, err := parse(tt.cmd)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := prefix(prog, tt.n)
+			if got != tt.want {
+				t.Errorf("prefix(%q, %d) = %q, want %q", tt.cmd, tt.n, got, tt.want)
+			}
+
+			// The prefix must be the same commands as the first n steps of the program.
+			flat, _ := expand(t, tt.cmd)
+			if exp, err := expand(t, got); err != nil || exp != flat[:tt.n] {
+				t.Errorf("prefix(%q, %d) expands to %q, want %q", tt.cmd, tt.n, exp, flat[:tt.n])
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package robot
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"slices"
+)
+
+/*
+Plan computes a command string of L, R and F commands that takes the robot from its current state to the target.
+The plan is the shortest possible command string, and of all the shortest strings the one with the fewest turns. If fewestTurns is true the number of turns is minimised first and the length of the string second.
+The plan respects the walls, blocked cells and the collision policy of the robot. In a shared room the other robots are treated as obstacles that stay where they are.
+*/
+func (r *Robot) Plan(target Coordinate, fewestTurns bool) (string, error) {
+	r.l.RLock()
+	defer r.l.RUnlock()
+
+	return r.clone().plan(target, fewestTurns)
+}
+
+/*
+Goto plans a route to the target like Plan and executes it like Cmd. Planning and executing is done atomically, so no other command string can be executed by the robot in between.
+The plan is returned together with the result of executing it. In a shared room the robot can still collide with robots that move into its path.
+*/
+func (r *Robot) Goto(target Coordinate, fewestTurns bool, opts ...CmdOption) (string, Result, error) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	res := Result{}
+	res.Direction, res.Coordinate = r.report()
+
+	if r.busy() {
+		return "", res, ErrBusy
+	}
+
+	cs, err := r.clone().plan(target, fewestTurns)
+	if err != nil || cs == "" {
+		return cs, res, err
+	}
+
+	prog, err := parse(cs)
+	if err != nil {
+		return "", res, err
+	}
+
+	r.record()
+	res, err = r.run(context.Background(), prog, newCmdConfig(opts))
+	r.emit(EventCmd, cs)
+
+	return cs, res, err
+}
+
+// A state in the search for a plan, i.e. a cell and the direction the robot is facing, the cost of reaching it and the priority of the state in the queue.
+type planItem struct {
+	state int
+	cost  int64
+	prio  int64
+}
+
+type planQueue []planItem
+
+// States with the same priority are ordered by cost, highest first, so the search follows the most promising route to the end instead of widening.
+func (q planQueue) Len() int { return len(q) }
+func (q planQueue) Less(i, j int) bool {
+	return q[i].prio < q[j].prio || (q[i].prio == q[j].prio && q[i].cost > q[j].cost)
+}
+func (q planQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *planQueue) Push(x interface{}) { *q = append(*q, x.(planItem)) }
+func (q *planQueue) Pop() interface{} {
+	old := *q
+	it := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return it
+}
+
+// Finds the cheapest plan. The robot must not be shared, see clone.
+func (r *Robot) plan(target Coordinate, fewestTurns bool) (string, error) {
+	if !r.room.inside(target) {
+		return "", fmt.Errorf("%w: the target is outside the room", ErrUnreachable)
+	}
+	if !r.room.free(target) {
+		return "", fmt.Errorf("%w: the target is blocked", ErrUnreachable)
+	}
+	// Searching for a target that can not be reached would go through every state that can.
+	if !r.reachable()[target.Y*r.room.X+target.X] {
+		return "", fmt.Errorf("%w: there is no route to the target", ErrUnreachable)
+	}
+
+	cmds, _, ok := newPlanner(r).route(r.coordinate, r.compass.index, target, fewestTurns)
+	if !ok {
+		return "", fmt.Errorf("%w: there is no route to the target", ErrUnreachable)
+	}
+
+	return string(cmds), nil
+}
+
+/*
+planner searches for routes with the A* algorithm over the combinations of cells and directions the robot can face, called states.
+Only the states that are reached by a search are stored, so a route between nearby cells is found without going through the whole room, see PlanSweep.
+*/
+type planner struct {
+	room   Room
+	mode   CompassMode
+	policy CollisionPolicy
+	// The number of directions of the compass. A state is numbered cell*points + direction, where cell is y*width + x and direction counts the directions of the compass clockwise from north.
+	points int
+	// The states that have been reached by the latest search.
+	reached map[int]planStep
+}
+
+// The cost of the cheapest way found to a state and the state before it on that way.
+type planStep struct {
+	cost int64
+	prev int32
+}
+
+func newPlanner(r *Robot) *planner {
+	return &planner{room: r.room, mode: r.compass.mode, policy: r.policy, points: len(directions) / int(r.compass.mode.step())}
+}
+
+func (p *planner) state(c Coordinate, index uint) int {
+	return int(c.Y*p.room.X+c.X)*p.points + int(index/p.mode.step())
+}
+
+// Returns the cell and the index of the direction of the state.
+func (p *planner) pose(s int) (Coordinate, uint) {
+	cell := uint(s / p.points)
+	return Coordinate{X: cell % p.room.X, Y: cell / p.room.X}, uint(s%p.points) * p.mode.step()
+}
+
+// Returns the fewest moves from the cell to the target, ignoring blocked cells. A diagonal move counts as one move with an eight-point compass.
+func (p *planner) moves(c, target Coordinate) int64 {
+	axis := func(a, b, size uint) int64 {
+		d := max(a, b) - min(a, b)
+		if p.policy == Wrap {
+			d = min(d, size-d)
+		}
+		return int64(d)
+	}
+
+	dx, dy := axis(c.X, target.X, p.room.X), axis(c.Y, target.Y, p.room.Y)
+	if p.mode == EightPoint {
+		return max(dx, dy)
+	}
+	return dx + dy
+}
+
+// Returns the commands that take the robot from the cell and direction to the target and the index of the direction it is facing at the target.
+// If there is no route ok is false.
+func (p *planner) route(from Coordinate, index uint, target Coordinate, fewestTurns bool) (cmds []byte, end uint, ok bool) {
+	states := p.room.cells() * p.points
+	p.reached = make(map[int]planStep)
+
+	// The cost of a plan is a pair of counts that is compared lexicographically, encoded as primary*states + secondary.
+	// No plan visits a state twice, so the secondary count is always less than the number of states.
+	move, turn := int64(states), int64(states)+1
+	if fewestTurns {
+		move, turn = 1, int64(states)+1
+	}
+
+	// Every move costs at least move and turns do not bring the robot closer, so the fewest moves to the target never overestimate the remaining cost.
+	estimate := func(c Coordinate) int64 { return p.moves(c, target) * move }
+
+	start := p.state(from, index)
+	p.reached[start] = planStep{prev: -1}
+	q := &planQueue{{state: start, prio: estimate(from)}}
+
+	goal := -1
+	for q.Len() > 0 {
+		it := heap.Pop(q).(planItem)
+		if it.cost > p.reached[it.state].cost {
+			continue
+		}
+
+		c, index := p.pose(it.state)
+		if c == target {
+			goal = it.state
+			break
+		}
+
+		step, n := p.mode.step(), uint(len(directions))
+		next := []planItem{
+			{state: p.state(c, (index+n-step)%n), cost: turn},
+			{state: p.state(c, (index+step)%n), cost: turn},
+		}
+		if f, ok := p.room.step(c, index, p.policy); ok {
+			next = append(next, planItem{state: p.state(f, index), cost: move})
+		}
+
+		for _, nx := range next {
+			cost := it.cost + nx.cost
+			if r, ok := p.reached[nx.state]; !ok || cost < r.cost {
+				p.reached[nx.state] = planStep{cost: cost, prev: int32(it.state)}
+				nc, _ := p.pose(nx.state)
+				heap.Push(q, planItem{state: nx.state, cost: cost, prio: cost + estimate(nc)})
+			}
+		}
+	}
+
+	if goal == -1 {
+		return nil, index, false
+	}
+
+	for s := goal; s != start; s = int(p.reached[s].prev) {
+		prev := int(p.reached[s].prev)
+		switch {
+		case prev/p.points != s/p.points:
+			cmds = append(cmds, 'F')
+		case (prev%p.points+1)%p.points == s%p.points:
+			cmds = append(cmds, 'R')
+		default:
+			cmds = append(cmds, 'L')
+		}
+	}
+	slices.Reverse(cmds)
+
+	_, end = p.pose(goal)
+	return cmds, end, true
+}
@@ -0,0 +1,122 @@
+package robot
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestRobotPlan(t *testing.T) {
+	wrapMap, err := NewGridMap([]string{
+		".....",
+		"##...",
+		".....",
+		"..#.#",
+		".##..",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		room        Room
+		d           string
+		start       Coordinate
+		opts        []Option
+		target      Coordinate
+		fewestTurns bool
+		want        string
+		err         error
+	}{
+		{Room{X: 5, Y: 5}, "N", Coordinate{X: 0, Y: 4}, nil, Coordinate{X: 2, Y: 2}, false, "FFRFF", nil},
+		{Room{X: 5, Y: 5}, "N", Coordinate{X: 0, Y: 4}, nil, Coordinate{X: 0, Y: 4}, false, "", nil},
+		{Room{X: 5, Y: 5}, "N", Coordinate{X: 0, Y: 4}, nil, Coordinate{X: 0, Y: 3}, false, "F", nil},
+		{Room{X: 5, Y: 5}, "N", Coordinate{X: 0, Y: 4}, nil, Coordinate{X: 0, Y: 5}, false, "", ErrUnreachable},
+		// Around an obstacle, where both ways around are equally good.
+		{Room{X: 3, Y: 3, Obstacles: []Coordinate{{X: 1, Y: 1}}}, "E", Coordinate{X: 0, Y: 1}, nil, Coordinate{X: 2, Y: 1}, false, "RFLFFLF", nil},
+		{Room{X: 3, Y: 3, Obstacles: []Coordinate{{X: 1, Y: 1}}}, "E", Coordinate{X: 0, Y: 1}, nil, Coordinate{X: 1, Y: 1}, false, "", ErrUnreachable},
+		{Room{X: 3, Y: 1, Obstacles: []Coordinate{{X: 1, Y: 0}}}, "E", Coordinate{X: 0, Y: 0}, nil, Coordinate{X: 2, Y: 0}, false, "", ErrUnreachable},
+		// Diagonal moves.
+		{Room{X: 5, Y: 5}, "N", Coordinate{X: 0, Y: 4}, []Option{WithCompassMode(EightPoint)}, Coordinate{X: 2, Y: 2}, false, "RFF", nil},
+		// Through the walls, where the shortest plan has more turns than necessary.
+		{Room{Map: wrapMap}, "E", Coordinate{X: 0, Y: 0}, []Option{WithCollisionPolicy(Wrap)}, Coordinate{X: 4, Y: 4}, false, "LFLF", nil},
+		{Room{Map: wrapMap}, "E", Coordinate{X: 0, Y: 0}, []Option{WithCollisionPolicy(Wrap)}, Coordinate{X: 4, Y: 4}, true, "FFFFLF", nil},
+	}
+
+	for _, tt := range tests {
+		tname := fmt.Sprintf("Test plan: %s %v to %v", tt.d, tt.start, tt.target)
+
+		t.Run(tname, func(t *testing.T) {
+			r, err := NewRobot(tt.room, tt.d, tt.start, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cs, err := r.Plan(tt.target, tt.fewestTurns)
+			if cs != tt.want || !errors.Is(err, tt.err) {
+				t.Errorf("Got %q %v, want %q %v", cs, err, tt.want, tt.err)
+			}
+
+			// Planning never moves the robot.
+			if d, c := r.Report(); d != tt.d || c != tt.start {
+				t.Errorf("The robot moved to %s %v", d, c)
+			}
+		})
+	}
+}
+
+func TestPlannerLargeRoom(t *testing.T) {
+	for _, mode := range []CompassMode{FourPoint, EightPoint} {
+		r, err := NewRobot(Room{X: 1000, Y: 1000}, "N", Coordinate{X: 0, Y: 999}, WithCompassMode(mode))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p := newPlanner(r)
+		cmds, _, ok := p.route(r.coordinate, r.compass.index, Coordinate{X: 999, Y: 0}, false)
+		if !ok || (mode == FourPoint && len(cmds) != 1999) || (mode == EightPoint && len(cmds) != 1000) {
+			t.Errorf("Got %d commands %v with %v", len(cmds), ok, mode)
+		}
+
+		// The search goes straight for the target instead of going through the whole room.
+		if len(p.reached) > 100000 {
+			t.Errorf("The search reached %d states with %v", len(p.reached), mode)
+		}
+	}
+}
+
+func TestRobotGoto(t *testing.T) {
+	sr, err := NewSharedRoom(Room{X: 3, Y: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := sr.NewRobot("N", Coordinate{X: 0, Y: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sr.NewRobot("N", Coordinate{X: 0, Y: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The other robot is in the way.
+	cs, res, err := r.Goto(Coordinate{X: 0, Y: 0}, false)
+	if err != nil || cs != "RFLFFLF" || res.Coordinate != (Coordinate{X: 0, Y: 0}) || res.Direction != "W" || res.Bumps() != 0 {
+		t.Errorf("Got %q %+v %v", cs, res, err)
+	}
+
+	// Already there, nothing is executed.
+	cs, res, err = r.Goto(Coordinate{X: 0, Y: 0}, false)
+	if err != nil || cs != "" || res.Coordinate != (Coordinate{X: 0, Y: 0}) {
+		t.Errorf("Got %q %+v %v", cs, res, err)
+	}
+
+	if _, _, err := r.Goto(Coordinate{X: 0, Y: 1}, false); !errors.Is(err, ErrUnreachable) {
+		t.Errorf("Got %v, want %v", err, ErrUnreachable)
+	}
+
+	// A goto can be undone like any other command string.
+	if d, c, err := r.Undo(); err != nil || d != "N" || c != (Coordinate{X: 0, Y: 2}) {
+		t.Errorf("Got %s %v %v", d, c, err)
+	}
+}
@@ -0,0 +1,59 @@
+package robot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CollisionPolicy decides what happens when the robot tries to move into a wall, a blocked cell or another robot.
+type CollisionPolicy uint8
+
+const (
+	// The robot stays where it is and continues with the next command. This is the default.
+	Clamp CollisionPolicy = iota
+	// The robot stays where it is and the rest of the command string is aborted with an error.
+	Stop
+	// The room is toroidal, moving through a wall brings the robot to the opposite side of the room.
+	// Moving into a blocked cell or another robot is handled the same way as with Clamp.
+	Wrap
+)
+
+var policyNames = []string{"clamp", "stop", "wrap"}
+
+// Parses the name of a collision policy, i.e. clamp, stop or wrap. An empty string is parsed as the default policy.
+func ParseCollisionPolicy(s string) (CollisionPolicy, error) {
+	if s == "" {
+		return Clamp, nil
+	}
+
+	for i, n := range policyNames {
+		if strings.EqualFold(n, s) {
+			return CollisionPolicy(i), nil
+		}
+	}
+
+	return Clamp, fmt.Errorf("%w %q, valid policies are %s", ErrInvalidPolicy, s, strings.Join(policyNames, ", "))
+}
+
+func (p CollisionPolicy) String() string {
+	if int(p) < len(policyNames) {
+		return policyNames[p]
+	}
+	return fmt.Sprintf("CollisionPolicy(%d)", p)
+}
+
+// Sets the collision policy of the robot. Robots use Clamp by default.
+func WithCollisionPolicy(p CollisionPolicy) Option {
+	return func(r *Robot) {
+		r.policy = p
+	}
+}
+
+// Returns the neighbouring coordinate in the direction with the given index, wrapping around to the opposite side of the room at the walls.
+func (r Room) wrap(c Coordinate, index uint) Coordinate {
+	d := deltas[index]
+	x := (int(c.X) + d.x + int(r.X)) % int(r.X)
+	y := (int(c.Y) + d.y + int(r.Y)) % int(r.Y)
+
+	return Coordinate{X: uint(x), Y: uint(y)}
+}
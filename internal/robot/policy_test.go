@@ -0,0 +1,73 @@
+package robot
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestParseCollisionPolicy(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    CollisionPolicy
+		wantErr bool
+	}{
+		{s: "", want: Clamp},
+		{s: "clamp", want: Clamp},
+		{s: "Stop", want: Stop},
+		{s: "WRAP", want: Wrap},
+		{s: "bounce", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := ParseCollisionPolicy(tt.s)
+			if errors.Is(err, ErrInvalidPolicy) != tt.wantErr || got != tt.want {
+				t.Errorf("ParseCollisionPolicy(%q) = %v %v, want %v", tt.s, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRobotCmdCollisionPolicy(t *testing.T) {
+	tests := []struct {
+		policy  CollisionPolicy
+		d       string
+		start   Coordinate
+		cmd     string
+		want_d  string
+		want_c  Coordinate
+		wantErr error
+	}{
+		{Clamp, "N", Coordinate{X: 2, Y: 1}, "FFFRF", "E", Coordinate{X: 2, Y: 0}, nil},
+		{Stop, "N", Coordinate{X: 2, Y: 1}, "FFFRF", "N", Coordinate{X: 2, Y: 0}, ErrCollision{Step: 1}},
+		{Stop, "E", Coordinate{X: 0, Y: 1}, "2[LR]F", "E", Coordinate{X: 0, Y: 1}, ErrCollision{Step: 4}},
+		{Stop, "S", Coordinate{X: 0, Y: 0}, "FRF", "W", Coordinate{X: 0, Y: 1}, ErrCollision{Step: 2}},
+		{Wrap, "N", Coordinate{X: 1, Y: 0}, "F", "N", Coordinate{X: 1, Y: 2}, nil},
+		{Wrap, "W", Coordinate{X: 0, Y: 0}, "FF", "W", Coordinate{X: 1, Y: 0}, nil},
+		{Wrap, "S", Coordinate{X: 2, Y: 2}, "FLFF", "E", Coordinate{X: 1, Y: 0}, nil},
+		// Blocked cells are not wrapped around.
+		{Wrap, "E", Coordinate{X: 0, Y: 1}, "FF", "E", Coordinate{X: 0, Y: 1}, nil},
+	}
+
+	for _, tt := range tests {
+		tname := fmt.Sprintf("Test cmd: %s %s", tt.policy, tt.cmd)
+
+		t.Run(tname, func(t *testing.T) {
+			r, err := NewRobot(Room{X: 3, Y: 3, Obstacles: []Coordinate{{X: 1, Y: 1}}}, tt.d, tt.start, WithCollisionPolicy(tt.policy))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res, err := r.Cmd(tt.cmd)
+			d, c := res.Direction, res.Coordinate
+
+			if d != tt.want_d || c != tt.want_c {
+				t.Errorf("failed to process cmd %s. Got: %v %s want: %v %s", tt.cmd, c, d, tt.want_c, tt.want_d)
+			}
+			if err != tt.wantErr {
+				t.Errorf("Got err %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
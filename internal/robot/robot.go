@@ -1,52 +1,148 @@
 package robot
 
 import (
+	"context"
 	"errors"
+	"slices"
 	"sync"
 	"unicode"
 )
 
-var directions = []rune{'N', 'E', 'S', 'W'}
+// All directions in clockwise order. A four-point compass only uses every other direction.
+var directions = []string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"}
+
+// The change in x and y when moving one cell in the direction with the same index in directions.
+var deltas = []struct{ x, y int }{{0, -1}, {1, -1}, {1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}}
+
+// CompassMode selects if the compass has four (N, E, S, W) or eight (N, NE, E, SE, S, SW, W, NW) directions.
+type CompassMode uint8
+
+const (
+	FourPoint CompassMode = iota
+	EightPoint
+)
+
+// Returns how many steps in directions a single turn moves the compass.
+func (m CompassMode) step() uint {
+	if m == EightPoint {
+		return 1
+	}
+	return 2
+}
 
 type Compass struct {
 	index uint
+	mode  CompassMode
 }
 
-// Creates a new compass set to one of the following directions N, E, S, W
-func NewCompass(d rune) *Compass {
-	var index uint
-	d = unicode.ToUpper(d)
-
-	for i, v := range directions {
-		if v == d {
-			index = uint(i)
-		}
+// Creates a new compass set to one of the directions available in the given mode, see ParseDirection for the accepted formats.
+func NewCompass(d string, m CompassMode) (*Compass, error) {
+	index, err := parseDirection(d, m)
+	if err != nil {
+		return nil, err
 	}
 
-	return &Compass{index: index}
+	return &Compass{index: index, mode: m}, nil
 }
 
 // Returns the current direction the compass is pointing towards.
-func (c *Compass) current() rune {
+func (c *Compass) current() string {
 	return directions[c.index]
 }
 
 // Updates the compass to point the next direction that is right of the current direction.
-// N->E->S->W->back to (N)orth
+// N->E->S->W->back to (N)orth or N->NE->E->SE->S->SW->W->NW->back to (N)orth
 func (c *Compass) turnR() {
-	c.index = (c.index + 1) % uint(len(directions))
+	c.index = (c.index + c.mode.step()) % uint(len(directions))
 }
 
 // Updates the compass to point to the next direction that is left of the current direction.
-// back to (N)orth<-E<-S<-W<-N
+// back to (N)orth<-E<-S<-W<-N or back to (N)orth<-NE<-E<-SE<-S<-SW<-W<-NW<-N
 func (c *Compass) turnL() {
-	c.index = (c.index - 1 + uint(len(directions))) % uint(len(directions))
+	c.index = (c.index - c.mode.step() + uint(len(directions))) % uint(len(directions))
 }
 
-// The Room struct is a record of the dimensions of the room that the robot is navigating in.
+/*
+The Room struct is a record of the dimensions of the room that the robot is navigating in.
+Obstacles is a list of cells inside the room that are blocked (furniture, pillars etc.) and can not be entered by the robot.
+Rooms that are not rectangular can be described with a Map. X and Y are then a shorthand that can be left out, but if they are set they must match the size of the map.
+Chargers is a list of cells with charging stations that charge the battery of a robot that is in the cell, see WithBattery.
+*/
 type Room struct {
-	X uint `json:"x"`
-	Y uint `json:"y"`
+	X         uint         `json:"x"`
+	Y         uint         `json:"y"`
+	Obstacles []Coordinate `json:"obstacles,omitempty"`
+	Map       *GridMap     `json:"map,omitempty"`
+	Chargers  []Coordinate `json:"chargers,omitempty"`
+	// The cells that are blocked by the map or an obstacle, so a cell can be looked up without going through all obstacles, see normalise.
+	grid *GridMap
+}
+
+// The most cells a room can have. Robots keep per-cell data, e.g. the coverage, so the size of the room must be bounded.
+const maxCells = 1 << 24
+
+// Fills in the room dimensions from the map if needed and merges the map and the obstacles into a single grid. An error is returned if the dimensions contradict the map, the room is empty or it has more than maxCells cells.
+func (r Room) normalise() (Room, error) {
+	if r.Map != nil {
+		x, y := r.Map.Size()
+		if (r.X != 0 && r.X != x) || (r.Y != 0 && r.Y != y) {
+			return r, ErrMapMismatch
+		}
+
+		r.X, r.Y = x, y
+	}
+
+	if r.X == 0 || r.Y == 0 {
+		return r, ErrEmptyRoom
+	}
+
+	// Compared without multiplying, since the product can overflow.
+	if r.X > maxCells/r.Y {
+		return r, ErrRoomTooLarge
+	}
+
+	r.grid = &GridMap{x: r.X, y: r.Y, blocked: make([]uint64, (r.cells()+63)/64)}
+	if r.Map != nil {
+		copy(r.grid.blocked, r.Map.blocked)
+	}
+	for _, o := range r.Obstacles {
+		if r.inside(o) {
+			r.grid.block(o)
+		}
+	}
+
+	return r, nil
+}
+
+// Returns a copy of a normalised room with more obstacles. The grid of the room is not modified.
+func (r Room) withObstacles(cs []Coordinate) Room {
+	r.Obstacles = append(append([]Coordinate(nil), r.Obstacles...), cs...)
+
+	grid := *r.grid
+	grid.blocked = slices.Clone(grid.blocked)
+	for _, c := range cs {
+		if r.inside(c) {
+			grid.block(c)
+		}
+	}
+	r.grid = &grid
+
+	return r
+}
+
+// Returns the number of cells in the bounding rectangle of a normalised room, which is at most maxCells.
+func (r Room) cells() int {
+	return int(r.X * r.Y)
+}
+
+// Returns true if the coordinate is inside the bounding rectangle of the room.
+func (r Room) inside(c Coordinate) bool {
+	return c.X < r.X && c.Y < r.Y
+}
+
+// Returns true if the coordinate is inside the room and not blocked by the map or an obstacle. The room must be normalised.
+func (r Room) free(c Coordinate) bool {
+	return r.inside(c) && !r.grid.Blocked(c)
 }
 
 /*
@@ -65,43 +161,251 @@ type Robot struct {
 	compass    Compass
 	room       Room
 	coordinate Coordinate
-	l          sync.RWMutex
+	policy     CollisionPolicy
+	// The room the robot shares with other robots, nil if the robot has a room of its own.
+	shared *SharedRoom
+	// States before previous command batches and states that have been undone, see history.go.
+	history []snapshot
+	future  []snapshot
+	// Receives an event for every change of the state, see events.go.
+	sink func(Event)
+	// The number of times each cell has been entered, indexed by y*width + x, see coverage.go.
+	visits []uint32
+	// The energy budget of the robot, nil if the robot never runs out of energy, see energy.go.
+	battery *battery
+	// The simulated time each command takes, the command strings queued for execution and the latest finished ones, see jobs.go.
+	timing   *Timing
+	jobs     []*job
+	finished []*job
+	jobId    uint64
+	// True while a command string is streamed to the robot, see stream.go.
+	streaming bool
+	l         sync.RWMutex
+}
+
+// Option configures optional behaviour of a robot when it is created by NewRobot.
+type Option func(*Robot)
+
+// Sets the compass mode of the robot. Robots use a four-point compass by default.
+func WithCompassMode(m CompassMode) Option {
+	return func(r *Robot) {
+		r.compass.mode = m
+	}
 }
 
 // Creates a new robot with an initial state according to the argument. If the state is invalid the return value will be nil and an error
-func NewRobot(r Room, d rune, c Coordinate) (*Robot, error) {
-	comp := NewCompass(d)
+func NewRobot(r Room, d string, c Coordinate, opts ...Option) (*Robot, error) {
+	rb := &Robot{}
+	for _, opt := range opts {
+		opt(rb)
+	}
+
+	comp, err := NewCompass(d, rb.compass.mode)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err = r.normalise()
+	if err != nil {
+		return nil, err
+	}
+
+	if !r.inside(c) {
+		return nil, ErrOutsideRoom
+	}
+
+	if !r.free(c) {
+		return nil, ErrBlockedCell
+	}
+
+	rb.compass, rb.coordinate, rb.room = *comp, c, r
+	rb.visits = make([]uint32, r.cells())
+	rb.visit(c)
+
+	if rb.shared != nil {
+		if err := rb.shared.enter(rb, c); err != nil {
+			return nil, err
+		}
+	}
+
+	rb.emit(EventCreate, "")
+	return rb, nil
+}
+
+// Result is the state of the robot after a command string has been executed together with information about the execution.
+type Result struct {
+	Direction  string
+	Coordinate Coordinate
+	// The index of every step where the robot collided with a wall, a blocked cell or another robot.
+	Collisions []int
+	// The state after every step, only recorded if the command string is executed with WithTrace.
+	Trace []Pose
+	// The number of steps that were executed, including steps that collided.
+	Steps int
+}
+
+// Pose is the direction and coordinate of a robot at one point in time.
+type Pose struct {
+	Direction  string
+	Coordinate Coordinate
+}
+
+// Progress is reported while a command string is executed, see WithProgress. Steps is the number of steps that have been executed.
+type Progress struct {
+	Steps int
+	Pose
+}
+
+// CmdOption configures how a command string is executed by Cmd and Simulate.
+type CmdOption func(*cmdConfig)
 
-	if c.X >= r.X || c.Y >= r.Y {
-		// TODO: This should probably be a customer error type.
-		return nil, errors.New("the robot coordinates are outside the room")
+type cmdConfig struct {
+	trace    bool
+	every    int
+	progress func(Progress)
+}
+
+// Records the state of the robot after every step in Result.Trace.
+func WithTrace() CmdOption {
+	return func(c *cmdConfig) {
+		c.trace = true
 	}
+}
 
-	return &Robot{compass: *comp, coordinate: c, room: r}, nil
+// Calls fn with the progress of the execution every time another n steps have been executed. fn is called while the robot is locked, so it must not call any methods of the robot, except by CmdStream, which only reports the latest progress of each instruction once it has been executed.
+func WithProgress(n int, fn func(Progress)) CmdOption {
+	return func(c *cmdConfig) {
+		if n > 0 {
+			c.every, c.progress = n, fn
+		}
+	}
+}
+
+func newCmdConfig(opts []CmdOption) cmdConfig {
+	c := cmdConfig{}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// Returns the number of times the robot collided with a wall, a blocked cell or another robot.
+func (res Result) Bumps() int {
+	return len(res.Collisions)
 }
 
 /*
 Cmd executes a series of commands on the robot and returns the new state of the robot.
-The commands will be executed one by one and the robots internal state will be updated.
-If an invalid command is encountered processing is stopped and the latest state of the robot is returned.
+The command string is parsed before anything is executed, see parse.go for the grammar. If the string can not be parsed the robot is not moved at all and the error describes the problem.
+The commands will then be executed one by one and the robots internal state will be updated.
+Every collision with a wall, a blocked cell or another robot is recorded in the result. If the collision policy of the robot is Stop, execution is aborted at the first collision and the error states the index of the step that collided.
+Steps are counted from 0 after repeats and groups have been expanded.
+If the robot has a battery, execution is aborted when there is not enough energy left for the next step and the error states the index of that step.
+*/
+func (r *Robot) Cmd(cs string, opts ...CmdOption) (Result, error) {
+	return r.CmdContext(context.Background(), cs, opts...)
+}
+
+/*
+CmdContext executes a command string like Cmd, but checks the context before every step and aborts the execution with an ErrCanceled when the context is done.
+The error states the index of the first step that was not executed and the result the state of the robot after the steps that were. Only the executed steps are recorded in the event log.
 */
-func (r *Robot) Cmd(cs string) (rune, Coordinate, error) {
+func (r *Robot) CmdContext(ctx context.Context, cs string, opts ...CmdOption) (Result, error) {
 	r.l.Lock()
 	defer r.l.Unlock()
 
-	for _, c := range cs {
-		err := r.doCmd(c)
-		if err != nil {
-			d, coo := r.report()
-			return d, coo, err
+	res := Result{}
+	res.Direction, res.Coordinate = r.report()
+
+	if r.busy() {
+		return res, ErrBusy
+	}
+
+	prog, err := parse(cs)
+	if err != nil {
+		return res, err
+	}
+
+	r.record()
+	res, err = r.run(ctx, prog, newCmdConfig(opts))
+
+	var canceled ErrCanceled
+	if errors.As(err, &canceled) {
+		cs = prefix(prog, canceled.Step)
+	}
+	r.emit(EventCmd, cs)
+
+	return res, err
+}
+
+// Executes a parsed command string and returns the new state of the robot together with the collisions. The execution is aborted when the context is done. The caller must hold the exclusive lock.
+func (r *Robot) run(ctx context.Context, prog []instruction, cfg cmdConfig) (Result, error) {
+	res := Result{}
+	err := r.steps(ctx, prog, cfg, &res)
+
+	res.Direction, res.Coordinate = r.report()
+	return res, err
+}
+
+// Executes a parsed command string as the steps that follow the steps that are already recorded in res. The execution is aborted when the context is done. The caller must hold the exclusive lock.
+func (r *Robot) steps(ctx context.Context, prog []instruction, cfg cmdConfig, res *Result) error {
+	return walk(prog, func(c rune) error {
+		if err := ctx.Err(); err != nil {
+			return ErrCanceled{Step: res.Steps, Err: err}
 		}
+
+		return r.exec(c, cfg, res)
+	})
+}
+
+// Executes the command as the next step and records it, the collisions and the trace in res. The caller must hold the exclusive lock.
+func (r *Robot) exec(c rune, cfg cmdConfig, res *Result) error {
+	step := res.Steps
+	if !r.use(c) {
+		return ErrOutOfEnergy{Step: step}
 	}
-	d, coo := r.report()
-	return d, coo, nil
+
+	bumped, err := r.doCmd(c)
+	if err != nil {
+		return err
+	}
+	r.chargeAtStation()
+	res.Steps++
+
+	if cfg.trace || cfg.progress != nil {
+		d, c := r.report()
+		if cfg.trace {
+			res.Trace = append(res.Trace, Pose{Direction: d, Coordinate: c})
+		}
+		if cfg.progress != nil && res.Steps%cfg.every == 0 {
+			cfg.progress(Progress{Steps: res.Steps, Pose: Pose{Direction: d, Coordinate: c}})
+		}
+	}
+
+	if bumped {
+		res.Collisions = append(res.Collisions, step)
+		if r.policy == Stop {
+			return ErrCollision{Step: step}
+		}
+	}
+
+	return nil
 }
 
-func (r *Robot) doCmd(c rune) error {
+/*
+Executes a single command and reports if the robot collided with a wall, a blocked cell or another robot:
+  - L turn left
+  - R turn right
+  - F move forward
+  - B move backward without turning
+  - < strafe left, i.e. move to the left without turning
+  - > strafe right, i.e. move to the right without turning
+
+The strafe commands move 90 degrees from the current direction regardless of the compass mode.
+*/
+func (r *Robot) doCmd(c rune) (bumped bool, err error) {
 	c = unicode.ToUpper(c)
+	quarter := uint(len(directions) / 4)
 
 	switch c {
 	case 'L':
@@ -109,38 +413,69 @@ func (r *Robot) doCmd(c rune) error {
 	case 'R':
 		r.compass.turnR()
 	case 'F':
-		switch r.compass.current() {
-		case 'S':
-			if r.coordinate.Y < r.room.Y-1 {
-				r.coordinate.Y++
-			}
-		case 'E':
-			if r.coordinate.X < r.room.X-1 {
-				r.coordinate.X++
-			}
-		case 'N':
-			if r.coordinate.Y > 0 {
-				r.coordinate.Y--
-			}
-		case 'W':
-			if r.coordinate.X > 0 {
-				r.coordinate.X--
-			}
-		}
+		return r.move(0), nil
+	case 'B':
+		return r.move(2 * quarter), nil
+	case '<':
+		return r.move(3 * quarter), nil
+	case '>':
+		return r.move(quarter), nil
 	default:
-		return errors.New("invalid command")
+		return false, ErrInvalidCommand{Index: -1, Rune: c}
 	}
 
-	return nil
+	return false, nil
+}
+
+// Moves the robot one cell in the direction that is offset steps clockwise from the direction the robot is facing.
+// If the robot collides with a wall, a blocked cell or another robot in a shared room it stays where it is and true is returned.
+// With the Wrap policy moving through a wall brings the robot to the opposite side of the room instead.
+func (r *Robot) move(offset uint) bool {
+	index := (r.compass.index + offset) % uint(len(directions))
+
+	next, ok := r.room.step(r.coordinate, index, r.policy)
+	if !ok {
+		return true
+	}
+
+	if r.shared != nil && !r.shared.move(r, r.coordinate, next) {
+		return true
+	}
+
+	r.coordinate = next
+	r.visit(next)
+	return false
+}
+
+// Returns the cell that is entered when moving one cell from c in the direction with the given index according to the collision policy.
+// If the move collides with a wall or a blocked cell ok is false.
+func (r Room) step(c Coordinate, index uint, p CollisionPolicy) (next Coordinate, ok bool) {
+	next, ok = neighbour(c, index)
+	if p == Wrap {
+		next, ok = r.wrap(c, index), true
+	}
+
+	return next, ok && r.free(next)
+}
+
+// Returns the neighbouring coordinate in the direction with the given index. If that coordinate would be negative ok is false.
+func neighbour(c Coordinate, index uint) (next Coordinate, ok bool) {
+	d := deltas[index]
+
+	if (d.x < 0 && c.X == 0) || (d.y < 0 && c.Y == 0) {
+		return c, false
+	}
+
+	return Coordinate{X: uint(int(c.X) + d.x), Y: uint(int(c.Y) + d.y)}, true
 }
 
 // This is not thread safe version of the Report function and is used to avoid deadlocks when functions that has taken a exclusive lock needs the report data.
-func (r *Robot) report() (rune, Coordinate) {
+func (r *Robot) report() (string, Coordinate) {
 	return r.compass.current(), r.coordinate
 }
 
 // This is a thread safe and exported wrapper for the un-exported report function.
-func (r *Robot) Report() (rune, Coordinate) {
+func (r *Robot) Report() (string, Coordinate) {
 	r.l.RLock()
 	defer r.l.RUnlock()
 
@@ -1,84 +1,176 @@
 package robot
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
 	"testing"
 )
 
+// Returns a compass for a direction that is known to be valid.
+func mustCompass(d string, m CompassMode) Compass {
+	c, err := NewCompass(d, m)
+	if err != nil {
+		panic(err)
+	}
+	return *c
+}
+
+// Normalises a room for robots that are created without NewRobot.
+func mustRoom(r Room) Room {
+	r, err := r.normalise()
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
 func TestNewCompass(t *testing.T) {
-	tests := []struct{ direction, want rune }{
-		{'N', 'N'},
-		{'E', 'E'},
-		{'S', 'S'},
-		{'W', 'W'},
-		{'A', 'N'},
-		{'n', 'N'},
-		{'e', 'E'},
-		{'s', 'S'},
-		{'w', 'W'},
+	tests := []struct{ direction, want string }{
+		{"N", "N"},
+		{"E", "E"},
+		{"S", "S"},
+		{"W", "W"},
+		{"A", ""},
+		{"n", "N"},
+		{"e", "E"},
+		{"s", "S"},
+		{"w", "W"},
+		{"North", "N"},
+		{"180", "S"},
+		{"NE", ""},
 	}
 
 	for _, tt := range tests {
-		tname := fmt.Sprintf("NewCompass: %s", string(tt.direction))
+		tname := fmt.Sprintf("NewCompass: %s", tt.direction)
 
 		t.Run(tname, func(t *testing.T) {
-			r := NewCompass(tt.direction)
-			if r.current() != tt.want {
-				t.Errorf("Got %s, want %s", string(r.current()), string(tt.want))
+			r, err := NewCompass(tt.direction, FourPoint)
+			if tt.want == "" {
+				if !errors.Is(err, ErrInvalidDirection) {
+					t.Errorf("Got %v, want %v", err, ErrInvalidDirection)
+				}
+				return
+			}
+			if err != nil || r.current() != tt.want {
+				t.Errorf("Got %v %v, want %s", r, err, tt.want)
 			}
 		})
 	}
 }
 
 func TestCompassTurnR(t *testing.T) {
-	tests := []struct{ direction, want rune }{
-		{'N', 'E'},
-		{'E', 'S'},
-		{'S', 'W'},
-		{'W', 'N'},
-		{'A', 'E'},
-		{'n', 'E'},
-		{'e', 'S'},
-		{'s', 'W'},
-		{'w', 'N'},
+	tests := []struct{ direction, want string }{
+		{"N", "E"},
+		{"E", "S"},
+		{"S", "W"},
+		{"W", "N"},
+		{"n", "E"},
+		{"e", "S"},
+		{"s", "W"},
+		{"w", "N"},
 	}
 
 	for _, tt := range tests {
-		tname := fmt.Sprintf("NewCompass: %s", string(tt.direction))
+		tname := fmt.Sprintf("NewCompass: %s", tt.direction)
 
 		t.Run(tname, func(t *testing.T) {
-			r := NewCompass(tt.direction)
+			r := mustCompass(tt.direction, FourPoint)
 			r.turnR()
 			if r.current() != tt.want {
-				t.Errorf("Got %s, want %s", string(r.current()), string(tt.want))
+				t.Errorf("Got %s, want %s", r.current(), tt.want)
 			}
 		})
 	}
 }
 
 func TestCompassTurnL(t *testing.T) {
-	tests := []struct{ direction, want rune }{
-		{'N', 'W'},
-		{'E', 'N'},
-		{'S', 'E'},
-		{'W', 'S'},
-		{'A', 'W'},
-		{'n', 'W'},
-		{'e', 'N'},
-		{'s', 'E'},
-		{'w', 'S'},
+	tests := []struct{ direction, want string }{
+		{"N", "W"},
+		{"E", "N"},
+		{"S", "E"},
+		{"W", "S"},
+		{"n", "W"},
+		{"e", "N"},
+		{"s", "E"},
+		{"w", "S"},
 	}
 
 	for _, tt := range tests {
-		tname := fmt.Sprintf("NewCompass: %s", string(tt.direction))
+		tname := fmt.Sprintf("NewCompass: %s", tt.direction)
 
 		t.Run(tname, func(t *testing.T) {
-			r := NewCompass(tt.direction)
+			r := mustCompass(tt.direction, FourPoint)
 			r.turnL()
 			if r.current() != tt.want {
-				t.Errorf("Got %s, want %s", string(r.current()), string(tt.want))
+				t.Errorf("Got %s, want %s", r.current(), tt.want)
+			}
+		})
+	}
+}
+
+func TestCompassEightPoint(t *testing.T) {
+	tests := []struct{ direction, want, wantR, wantL string }{
+		{"N", "N", "NE", "NW"},
+		{"NE", "NE", "E", "N"},
+		{"se", "SE", "S", "E"},
+		{"SW", "SW", "W", "S"},
+		{"NW", "NW", "N", "W"},
+		{"north-west", "NW", "N", "W"},
+		{"135", "SE", "S", "E"},
+	}
+
+	for _, tt := range tests {
+		tname := fmt.Sprintf("NewCompass: %s", tt.direction)
+
+		t.Run(tname, func(t *testing.T) {
+			c := mustCompass(tt.direction, EightPoint)
+			if c.current() != tt.want {
+				t.Errorf("Got %s, want %s", c.current(), tt.want)
+			}
+
+			r, l := c, c
+			r.turnR()
+			l.turnL()
+			if r.current() != tt.wantR || l.current() != tt.wantL {
+				t.Errorf("Got R: %s L: %s, want R: %s L: %s", r.current(), l.current(), tt.wantR, tt.wantL)
+			}
+		})
+	}
+
+}
+
+func TestRobotCmdEightPoint(t *testing.T) {
+	tests := []struct {
+		start  Coordinate
+		d      string
+		cmd    string
+		want_d string
+		want_c Coordinate
+	}{
+		{Coordinate{X: 2, Y: 2}, "NE", "F", "NE", Coordinate{X: 3, Y: 1}},
+		{Coordinate{X: 2, Y: 2}, "N", "RFRRFF", "SE", Coordinate{X: 4, Y: 2}},
+		{Coordinate{X: 2, Y: 2}, "N", "LFF", "NW", Coordinate{X: 0, Y: 0}},
+		// Diagonal moves into a wall leave the robot where it is.
+		{Coordinate{X: 0, Y: 2}, "SW", "F", "SW", Coordinate{X: 0, Y: 2}},
+		{Coordinate{X: 4, Y: 4}, "SE", "FLF", "E", Coordinate{X: 4, Y: 4}},
+	}
+
+	for _, tt := range tests {
+		tname := fmt.Sprintf("Test cmd: %s %s", tt.d, tt.cmd)
+
+		t.Run(tname, func(t *testing.T) {
+			r, err := NewRobot(Room{X: 5, Y: 5}, tt.d, tt.start, WithCompassMode(EightPoint))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res, _ := r.Cmd(tt.cmd)
+			d, c := res.Direction, res.Coordinate
+
+			if d != tt.want_d || c != tt.want_c {
+				t.Errorf("failed to process cmd %s. Got: %v %s want: %v %s", tt.cmd, c, d, tt.want_c, tt.want_d)
 			}
 		})
 	}
@@ -88,33 +180,41 @@ func TestRobotCmd(t *testing.T) {
 	tests := []struct {
 		robot  *Robot
 		cmd    string
-		want_d rune
+		want_d string
 		want_c Coordinate
 	}{
-		{&Robot{room: Room{X: 3, Y: 3},
+		{&Robot{room: mustRoom(Room{X: 3, Y: 3}),
 			coordinate: Coordinate{X: 1, Y: 1},
-			compass:    *NewCompass('N')}, "L",
-			'W', Coordinate{X: 1, Y: 1}},
-		{&Robot{room: Room{X: 3, Y: 3},
+			compass:    mustCompass("N", FourPoint)}, "L",
+			"W", Coordinate{X: 1, Y: 1}},
+		{&Robot{room: mustRoom(Room{X: 3, Y: 3}),
 			coordinate: Coordinate{X: 1, Y: 1},
-			compass:    *NewCompass('N')}, "R",
-			'E', Coordinate{X: 1, Y: 1}},
-		{&Robot{room: Room{X: 3, Y: 3},
+			compass:    mustCompass("N", FourPoint)}, "R",
+			"E", Coordinate{X: 1, Y: 1}},
+		{&Robot{room: mustRoom(Room{X: 3, Y: 3}),
 			coordinate: Coordinate{X: 1, Y: 1},
-			compass:    *NewCompass('N')}, "F",
-			'N', Coordinate{X: 1, Y: 0}},
-		{&Robot{room: Room{X: 5, Y: 5},
+			compass:    mustCompass("N", FourPoint)}, "F",
+			"N", Coordinate{X: 1, Y: 0}},
+		{&Robot{room: mustRoom(Room{X: 5, Y: 5}),
 			coordinate: Coordinate{X: 1, Y: 2},
-			compass:    *NewCompass('N')}, "RFRFFRFRF",
-			'N', Coordinate{X: 1, Y: 3}},
-		{&Robot{room: Room{X: 5, Y: 5},
+			compass:    mustCompass("N", FourPoint)}, "RFRFFRFRF",
+			"N", Coordinate{X: 1, Y: 3}},
+		{&Robot{room: mustRoom(Room{X: 5, Y: 5}),
 			coordinate: Coordinate{X: 0, Y: 0},
-			compass:    *NewCompass('E')}, "RFLFFLRF",
-			'E', Coordinate{X: 3, Y: 1}},
-		{&Robot{room: Room{X: 1, Y: 1},
+			compass:    mustCompass("E", FourPoint)}, "RFLFFLRF",
+			"E", Coordinate{X: 3, Y: 1}},
+		{&Robot{room: mustRoom(Room{X: 1, Y: 1}),
 			coordinate: Coordinate{X: 0, Y: 0},
-			compass:    *NewCompass('E')}, "RFLFFLRF",
-			'E', Coordinate{X: 0, Y: 0}},
+			compass:    mustCompass("E", FourPoint)}, "RFLFFLRF",
+			"E", Coordinate{X: 0, Y: 0}},
+		{&Robot{room: mustRoom(Room{X: 3, Y: 3, Obstacles: []Coordinate{{X: 1, Y: 0}}}),
+			coordinate: Coordinate{X: 1, Y: 1},
+			compass:    mustCompass("N", FourPoint)}, "FRFLF",
+			"N", Coordinate{X: 2, Y: 0}},
+		{&Robot{room: mustRoom(Room{X: 3, Y: 3, Obstacles: []Coordinate{{X: 2, Y: 1}}}),
+			coordinate: Coordinate{X: 0, Y: 1},
+			compass:    mustCompass("E", FourPoint)}, "FFF",
+			"E", Coordinate{X: 1, Y: 1}},
 	}
 
 	for _, tt := range tests {
@@ -128,16 +228,217 @@ func TestRobotCmd(t *testing.T) {
 			d, c := r.Report()
 
 			if d != tt.want_d || c.X != tt.want_c.X || c.Y != tt.want_c.Y {
-				t.Errorf("failed to process cmd %s. Got: %d %d %s want: %d %d %s", string(tt.cmd), c.X, c.Y, string(d), tt.want_c.X, tt.want_c.Y, string(tt.want_d))
+				t.Errorf("failed to process cmd %s. Got: %d %d %s want: %d %d %s", string(tt.cmd), c.X, c.Y, d, tt.want_c.X, tt.want_c.Y, tt.want_d)
+			}
+		})
+	}
+}
+
+func TestRobotCmdBackwardAndStrafe(t *testing.T) {
+	tests := []struct {
+		mode   CompassMode
+		d      string
+		start  Coordinate
+		cmd    string
+		want_d string
+		want_c Coordinate
+	}{
+		{FourPoint, "N", Coordinate{X: 2, Y: 2}, "B", "N", Coordinate{X: 2, Y: 3}},
+		{FourPoint, "N", Coordinate{X: 2, Y: 2}, "<", "N", Coordinate{X: 1, Y: 2}},
+		{FourPoint, "N", Coordinate{X: 2, Y: 2}, ">", "N", Coordinate{X: 3, Y: 2}},
+		{FourPoint, "E", Coordinate{X: 2, Y: 2}, "B<", "E", Coordinate{X: 1, Y: 1}},
+		{FourPoint, "W", Coordinate{X: 2, Y: 2}, ">>>", "W", Coordinate{X: 2, Y: 0}},
+		// Backing and strafing into walls and obstacles leaves the robot where it is.
+		{FourPoint, "N", Coordinate{X: 0, Y: 4}, "B<", "N", Coordinate{X: 0, Y: 4}},
+		{FourPoint, "N", Coordinate{X: 3, Y: 2}, "B", "N", Coordinate{X: 3, Y: 2}},
+		{EightPoint, "NE", Coordinate{X: 2, Y: 2}, ">", "NE", Coordinate{X: 2, Y: 2}},
+		{EightPoint, "NE", Coordinate{X: 2, Y: 2}, "B", "NE", Coordinate{X: 1, Y: 3}},
+		{EightPoint, "NE", Coordinate{X: 2, Y: 2}, "<", "NE", Coordinate{X: 1, Y: 1}},
+		{EightPoint, "SW", Coordinate{X: 2, Y: 2}, ">", "SW", Coordinate{X: 1, Y: 1}},
+	}
+
+	for _, tt := range tests {
+		tname := fmt.Sprintf("Test cmd: %s %s", tt.d, tt.cmd)
+
+		t.Run(tname, func(t *testing.T) {
+			r, err := NewRobot(Room{X: 5, Y: 5, Obstacles: []Coordinate{{X: 3, Y: 3}}}, tt.d, tt.start, WithCompassMode(tt.mode))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res, err := r.Cmd(tt.cmd)
+			d, c := res.Direction, res.Coordinate
+
+			if err != nil || d != tt.want_d || c != tt.want_c {
+				t.Errorf("failed to process cmd %s. Got: %v %s %v want: %v %s", tt.cmd, c, d, err, tt.want_c, tt.want_d)
 			}
 		})
 	}
 }
 
+func TestRobotCmdCollisions(t *testing.T) {
+	tests := []struct {
+		policy CollisionPolicy
+		cmd    string
+		want   []int
+	}{
+		{Clamp, "FF", nil},
+		{Clamp, "FFFRFFF", []int{2, 6}},
+		{Clamp, "3[F]<", []int{2, 3}},
+		{Stop, "FFFRFFF", []int{2}},
+		{Wrap, "FFFRFFF", []int{4, 5, 6}},
+	}
+
+	for _, tt := range tests {
+		tname := fmt.Sprintf("Test cmd: %s %s", tt.policy, tt.cmd)
+
+		t.Run(tname, func(t *testing.T) {
+			r, err := NewRobot(Room{X: 3, Y: 3, Obstacles: []Coordinate{{X: 1, Y: 2}}}, "N", Coordinate{X: 0, Y: 2}, WithCollisionPolicy(tt.policy))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res, _ := r.Cmd(tt.cmd)
+
+			if !reflect.DeepEqual(res.Collisions, tt.want) || res.Bumps() != len(tt.want) {
+				t.Errorf("Got collisions %v bumps %d, want %v", res.Collisions, res.Bumps(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRobotCmdTrace(t *testing.T) {
+	r, err := NewRobot(Room{X: 2, Y: 2}, "N", Coordinate{X: 0, Y: 1}, WithCollisionPolicy(Stop))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, _ := r.Cmd("FF")
+	if res.Trace != nil {
+		t.Errorf("Got trace %v without WithTrace", res.Trace)
+	}
+
+	r.Undo()
+
+	// The step that collides is the last one in the trace.
+	res, err = r.Cmd("2[RF]F", WithTrace())
+	want := []Pose{
+		{"E", Coordinate{X: 0, Y: 1}},
+		{"E", Coordinate{X: 1, Y: 1}},
+		{"S", Coordinate{X: 1, Y: 1}},
+		{"S", Coordinate{X: 1, Y: 1}},
+	}
+	if !reflect.DeepEqual(res.Trace, want) || err != (ErrCollision{Step: 3}) {
+		t.Errorf("Got trace %v %v, want %v", res.Trace, err, want)
+	}
+}
+
+// A context that is canceled after its Err method has been called n times.
+type countdownContext struct {
+	context.Context
+	n int
+}
+
+func (c *countdownContext) Err() error {
+	if c.n == 0 {
+		return context.Canceled
+	}
+	c.n--
+	return nil
+}
+
+func TestRobotCmdContext(t *testing.T) {
+	var events []Event
+	r, err := NewRobot(Room{X: 5, Y: 5}, "E", Coordinate{X: 0, Y: 0}, WithEventSink(func(e Event) {
+		events = append(events, e)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if res, err := r.CmdContext(ctx, "FF"); err != (ErrCanceled{Step: 0, Err: context.Canceled}) || res.Coordinate != (Coordinate{X: 0, Y: 0}) {
+		t.Errorf("Got %+v %v, want to be canceled before the first step", res, err)
+	}
+
+	res, err := r.CmdContext(&countdownContext{Context: context.Background(), n: 5}, "2[3FR]")
+	if !errors.Is(err, context.Canceled) || err != (ErrCanceled{Step: 5, Err: context.Canceled}) {
+		t.Errorf("Got %v, want to be canceled at step 5", err)
+	}
+	if res.Direction != "S" || res.Coordinate != (Coordinate{X: 3, Y: 1}) {
+		t.Errorf("Got %s %v, want S {3 1}", res.Direction, res.Coordinate)
+	}
+
+	// Only the executed steps are logged, so the log can be replayed.
+	if len(events) != 3 || events[1].Cmd != "" || events[2].Cmd != "3FRF" {
+		t.Errorf("Got events %+v", events)
+	}
+	if _, err := Replay(Room{X: 5, Y: 5}, events); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRobotCmdRepeat(t *testing.T) {
+	r, err := NewRobot(Room{X: 20, Y: 20}, "E", Coordinate{X: 0, Y: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res, err := r.Cmd("10FR2[3F]"); err != nil || res.Direction != "S" || res.Coordinate != (Coordinate{X: 10, Y: 6}) {
+		t.Errorf("Got %v %v", res, err)
+	}
+
+	// A syntax error anywhere in the string means that nothing is executed.
+	if res, err := r.Cmd("FFFF2[F"); err == nil || res.Direction != "S" || res.Coordinate != (Coordinate{X: 10, Y: 6}) {
+		t.Errorf("Got %v %v", res, err)
+	}
+}
+
+func TestRobotCmdGridMap(t *testing.T) {
+	// An L-shaped room.
+	m, err := NewGridMap([]string{
+		"..##",
+		"..##",
+		"....",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewRobot(Room{Map: m}, "N", Coordinate{X: 0, Y: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Going east along the bottom row works all the way to the east wall.
+	r.Cmd("RFFFF")
+	if d, c := r.Report(); d != "E" || c != (Coordinate{X: 3, Y: 2}) {
+		t.Errorf("Got %s %v", d, c)
+	}
+
+	// Going north from the bottom right corner is blocked by the map.
+	r.Cmd("LFF")
+	if d, c := r.Report(); d != "N" || c != (Coordinate{X: 3, Y: 2}) {
+		t.Errorf("Got %s %v", d, c)
+	}
+
+	// Going north in the west part of the room works.
+	r.Cmd("LFFRFF")
+	if d, c := r.Report(); d != "N" || c != (Coordinate{X: 1, Y: 0}) {
+		t.Errorf("Got %s %v", d, c)
+	}
+}
+
 func TestNewRobot(t *testing.T) {
+	m, err := NewGridMap([]string{"..#", "..."})
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	type args struct {
 		r Room
-		d rune
+		d string
 		c Coordinate
 	}
 	tests := []struct {
@@ -150,35 +451,82 @@ func TestNewRobot(t *testing.T) {
 			name: "Valid robot",
 			args: args{
 				r: Room{X: 3, Y: 3},
-				d: 'N',
+				d: "N",
 				c: Coordinate{X: 1, Y: 1},
 			},
-			want: &Robot{room: Room{X: 3, Y: 3}, compass: *NewCompass('N'), coordinate: Coordinate{X: 1, Y: 1}},
+			want: &Robot{room: mustRoom(Room{X: 3, Y: 3}), compass: mustCompass("N", FourPoint), coordinate: Coordinate{X: 1, Y: 1}, visits: []uint32{0, 0, 0, 0, 1, 0, 0, 0, 0}},
 		},
 		{
 			name: "Valid robot",
 			args: args{
 				r: Room{X: 3, Y: 3},
-				d: 'N',
+				d: "N",
 				c: Coordinate{X: 1, Y: 1},
 			},
-			want:    &Robot{room: Room{X: 3, Y: 3}, compass: *NewCompass('N'), coordinate: Coordinate{X: 1, Y: 1}},
+			want:    &Robot{room: mustRoom(Room{X: 3, Y: 3}), compass: mustCompass("N", FourPoint), coordinate: Coordinate{X: 1, Y: 1}, visits: []uint32{0, 0, 0, 0, 1, 0, 0, 0, 0}},
 			wantErr: nil,
 		},
 		{
 			name: "Robot created outside the room",
 			args: args{
 				r: Room{X: 1, Y: 1},
-				d: 'N',
+				d: "N",
 				c: Coordinate{X: 1, Y: 0},
 			},
 			want:    nil,
-			wantErr: errors.New("the robot coordinates are outside the room"),
+			wantErr: ErrOutsideRoom,
+		},
+		{
+			name: "Robot created on an obstacle",
+			args: args{
+				r: Room{X: 3, Y: 3, Obstacles: []Coordinate{{X: 1, Y: 1}}},
+				d: "N",
+				c: Coordinate{X: 1, Y: 1},
+			},
+			want:    nil,
+			wantErr: ErrBlockedCell,
+		},
+		{
+			name:    "Robot in an empty room",
+			args:    args{r: Room{X: 0, Y: 3}, d: "N", c: Coordinate{X: 0, Y: 0}},
+			want:    nil,
+			wantErr: ErrEmptyRoom,
+		},
+		{
+			name:    "Robot in a room with too many cells",
+			args:    args{r: Room{X: 1 << 32, Y: 1 << 32}, d: "N", c: Coordinate{X: 0, Y: 0}},
+			want:    nil,
+			wantErr: ErrRoomTooLarge,
+		},
+		{
+			name:    "Robot with an invalid direction",
+			args:    args{r: Room{X: 3, Y: 3}, d: "A", c: Coordinate{X: 0, Y: 0}},
+			want:    nil,
+			wantErr: ErrInvalidDirection,
+		},
+		{
+			name:    "Valid robot in a map room",
+			args:    args{r: Room{Map: m}, d: "N", c: Coordinate{X: 1, Y: 0}},
+			want:    &Robot{room: mustRoom(Room{X: 3, Y: 2, Map: m}), compass: mustCompass("N", FourPoint), coordinate: Coordinate{X: 1, Y: 0}, visits: []uint32{0, 1, 0, 0, 0, 0}},
+			wantErr: nil,
+		},
+		{
+			name:    "Robot created on a blocked map cell",
+			args:    args{r: Room{Map: m}, d: "N", c: Coordinate{X: 2, Y: 0}},
+			want:    nil,
+			wantErr: ErrBlockedCell,
+		},
+		{
+			name:    "Room dimensions that do not match the map",
+			args:    args{r: Room{X: 4, Y: 2, Map: m}, d: "N", c: Coordinate{X: 0, Y: 0}},
+			want:    nil,
+			wantErr: ErrMapMismatch,
 		},
 	}
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got, err := NewRobot(tt.args.r, tt.args.d, tt.args.c); !(reflect.DeepEqual(got, tt.want) && reflect.DeepEqual(err, tt.wantErr)) {
+			if got, err := NewRobot(tt.args.r, tt.args.d, tt.args.c); !(reflect.DeepEqual(got, tt.want) && errors.Is(err, tt.wantErr)) {
 				t.Errorf("NewRobot() = %v, want %v err %v want %v", got, tt.want, err, tt.wantErr)
 			}
 		})
@@ -0,0 +1,103 @@
+package robot
+
+import (
+	"fmt"
+)
+
+// Obstruction is what a distance sensor detects.
+type Obstruction uint8
+
+const (
+	// One of the walls of the room.
+	Wall Obstruction = iota
+	// An obstacle or a blocked cell of the map.
+	Obstacle
+	// Another robot in a shared room.
+	OtherRobot
+	// Nothing, only possible with the Wrap policy where the robot can see through the walls.
+	Nothing
+)
+
+var obstructionNames = []string{"wall", "obstacle", "robot", "nothing"}
+
+func (o Obstruction) String() string {
+	if int(o) < len(obstructionNames) {
+		return obstructionNames[o]
+	}
+	return fmt.Sprintf("Obstruction(%d)", o)
+}
+
+// Reading is the measurement of a distance sensor. Distance is the number of free cells between the robot and the obstruction.
+// If the obstruction is Nothing, Distance is the number of cells the sensor sees before it sees the robot itself.
+type Reading struct {
+	Distance    uint
+	Obstruction Obstruction
+}
+
+// Sensors are the readings of the distance sensors of a robot, in front of it and 90 degrees to its left and right.
+type Sensors struct {
+	Front Reading
+	Left  Reading
+	Right Reading
+}
+
+/*
+Sense measures the distance from the robot to the nearest wall, obstacle or other robot in front of it and to its left and right.
+The sensors look in the same directions as the robot moves with F, < and >, so diagonally in front of a robot with an eight-point compass that is facing NE, SE, SW or NW.
+With the Wrap policy the sensors look through the walls, the same way the robot moves through them.
+*/
+func (r *Robot) Sense() Sensors {
+	r.l.RLock()
+	defer r.l.RUnlock()
+
+	return r.sense()
+}
+
+// This is not thread safe version of the Sense function. The caller must hold the lock.
+func (r *Robot) sense() Sensors {
+	others := make(map[Coordinate]bool)
+	if r.shared != nil {
+		for _, c := range r.shared.others(r) {
+			others[c] = true
+		}
+	}
+
+	quarter := uint(len(directions) / 4)
+	return Sensors{
+		Front: r.scan(0, others),
+		Left:  r.scan(3*quarter, others),
+		Right: r.scan(quarter, others),
+	}
+}
+
+// Returns the reading of a sensor that looks in the direction that is offset steps clockwise from the direction the robot is facing.
+func (r *Robot) scan(offset uint, others map[Coordinate]bool) Reading {
+	index := (r.compass.index + offset) % uint(len(directions))
+	c := r.coordinate
+	res := Reading{}
+
+	for {
+		next, ok := neighbour(c, index)
+		if r.policy == Wrap {
+			next, ok = r.room.wrap(c, index), true
+		}
+
+		switch {
+		case !ok || !r.room.inside(next):
+			res.Obstruction = Wall
+			return res
+		case next == r.coordinate:
+			res.Obstruction = Nothing
+			return res
+		case !r.room.free(next):
+			res.Obstruction = Obstacle
+			return res
+		case others[next]:
+			res.Obstruction = OtherRobot
+			return res
+		}
+
+		c = next
+		res.Distance++
+	}
+}
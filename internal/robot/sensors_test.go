@@ -0,0 +1,57 @@
+package robot
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRobotSense(t *testing.T) {
+	tests := []struct {
+		room  Room
+		d     string
+		start Coordinate
+		opts  []Option
+		want  Sensors
+	}{
+		{Room{X: 5, Y: 3}, "N", Coordinate{X: 1, Y: 2}, nil, Sensors{Reading{2, Wall}, Reading{1, Wall}, Reading{3, Wall}}},
+		{Room{X: 5, Y: 3, Obstacles: []Coordinate{{X: 3, Y: 2}}}, "E", Coordinate{X: 1, Y: 2}, nil, Sensors{Reading{1, Obstacle}, Reading{2, Wall}, Reading{0, Wall}}},
+		// Diagonally.
+		{Room{X: 5, Y: 3}, "NE", Coordinate{X: 1, Y: 2}, []Option{WithCompassMode(EightPoint)}, Sensors{Reading{2, Wall}, Reading{1, Wall}, Reading{0, Wall}}},
+		// Through the walls.
+		{Room{X: 5, Y: 3, Obstacles: []Coordinate{{X: 0, Y: 2}}}, "E", Coordinate{X: 1, Y: 2}, []Option{WithCollisionPolicy(Wrap)}, Sensors{Reading{3, Obstacle}, Reading{2, Nothing}, Reading{2, Nothing}}},
+	}
+
+	for _, tt := range tests {
+		tname := fmt.Sprintf("Test sense: %v %s %v", tt.room, tt.d, tt.start)
+
+		t.Run(tname, func(t *testing.T) {
+			r, err := NewRobot(tt.room, tt.d, tt.start, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := r.Sense(); got != tt.want {
+				t.Errorf("Got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRobotSenseSharedRoom(t *testing.T) {
+	sr, err := NewSharedRoom(Room{X: 5, Y: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := sr.NewRobot("E", Coordinate{X: 0, Y: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sr.NewRobot("N", Coordinate{X: 3, Y: 0}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := r.Sense().Front; got != (Reading{2, OtherRobot}) {
+		t.Errorf("Got %+v, want 2 %v", got, OtherRobot)
+	}
+}
@@ -0,0 +1,112 @@
+package robot
+
+import (
+	"sync"
+)
+
+/*
+SharedRoom is a room that several robots live in. Two robots can never occupy the same cell, a robot that tries to move into a cell occupied by another robot collides with it the same way it would collide with a wall or a blocked cell.
+
+Locking order: a robot always takes its own lock before the lock of the shared room, and the shared room never takes the lock of a robot.
+This way a robot can hold its own lock while executing a whole command string, which keeps Robot.Report consistent, while the lock of the shared room prevents two robots from racing into the same cell.
+*/
+type SharedRoom struct {
+	room     Room
+	occupied map[Coordinate]*Robot
+	closed   bool
+	l        sync.Mutex
+}
+
+// Creates a new shared room without any robots in it.
+func NewSharedRoom(r Room) (*SharedRoom, error) {
+	r, err := r.normalise()
+	if err != nil {
+		return nil, err
+	}
+
+	return &SharedRoom{room: r, occupied: make(map[Coordinate]*Robot)}, nil
+}
+
+// Returns the layout of the room.
+func (sr *SharedRoom) Room() Room {
+	return sr.room
+}
+
+// Returns the number of robots in the room.
+func (sr *SharedRoom) Len() int {
+	sr.l.Lock()
+	defer sr.l.Unlock()
+
+	return len(sr.occupied)
+}
+
+/*
+Closes the room so that no new robots can be created in it. A room can only be closed when there are no robots in it.
+Checking for robots and closing the room is done atomically, so a robot can not be created in a room while it is being closed.
+*/
+func (sr *SharedRoom) Close() error {
+	sr.l.Lock()
+	defer sr.l.Unlock()
+
+	if len(sr.occupied) > 0 {
+		return ErrRoomNotEmpty
+	}
+
+	sr.closed = true
+	return nil
+}
+
+// Creates a new robot in the shared room. The starting coordinates must not be occupied by another robot.
+func (sr *SharedRoom) NewRobot(d string, c Coordinate, opts ...Option) (*Robot, error) {
+	opts = append(opts, func(r *Robot) {
+		r.shared = sr
+	})
+
+	return NewRobot(sr.room, d, c, opts...)
+}
+
+// Places the robot in the cell. Returns an error if the cell is occupied or the room is closed.
+func (sr *SharedRoom) enter(r *Robot, c Coordinate) error {
+	sr.l.Lock()
+	defer sr.l.Unlock()
+
+	if sr.closed {
+		return ErrRoomClosed
+	}
+
+	if o, ok := sr.occupied[c]; ok && o != r {
+		return ErrOccupied
+	}
+
+	sr.occupied[c] = r
+	return nil
+}
+
+// Moves the robot from one cell to another if the new cell is not occupied by another robot. Returns false if it is.
+func (sr *SharedRoom) move(r *Robot, from, to Coordinate) bool {
+	sr.l.Lock()
+	defer sr.l.Unlock()
+
+	if o, ok := sr.occupied[to]; ok && o != r {
+		return false
+	}
+
+	delete(sr.occupied, from)
+	sr.occupied[to] = r
+	return true
+}
+
+// Returns the coordinates of all robots in the room except r.
+func (sr *SharedRoom) others(r *Robot) []Coordinate {
+	sr.l.Lock()
+	defer sr.l.Unlock()
+
+	var cs []Coordinate
+	for c, o := range sr.occupied {
+		if o != r {
+			cs = append(cs, c)
+		}
+	}
+
+	return cs
+}
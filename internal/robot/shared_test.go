@@ -0,0 +1,127 @@
+package robot
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestSharedRoomCollision(t *testing.T) {
+	sr, err := NewSharedRoom(Room{X: 3, Y: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := sr.NewRobot("E", Coordinate{X: 0, Y: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := sr.NewRobot("N", Coordinate{X: 2, Y: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := sr.NewRobot("N", Coordinate{X: 2, Y: 0}); !errors.Is(err, ErrOccupied) {
+		t.Error("expected an error when creating a robot on an occupied cell")
+	}
+	if sr.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", sr.Len())
+	}
+
+	// a can move up to b but not into the cell b occupies.
+	res, err := a.Cmd("FF")
+	if err != nil || res.Coordinate != (Coordinate{X: 1, Y: 0}) || !reflect.DeepEqual(res.Collisions, []int{1}) {
+		t.Errorf("Got %v %v", res, err)
+	}
+
+	// When b has moved away the cell is free again.
+	b.Cmd("RRF")
+	res, err = a.Cmd("F")
+	if err != nil || res.Coordinate != (Coordinate{X: 2, Y: 0}) || res.Bumps() != 0 {
+		t.Errorf("Got %v %v", res, err)
+	}
+
+	// Robots with the Stop policy stop when they collide with another robot.
+	c, err := sr.NewRobot("N", Coordinate{X: 2, Y: 2}, WithCollisionPolicy(Stop))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, err = c.Cmd("FF"); err != (ErrCollision{Step: 0}) || res.Coordinate != (Coordinate{X: 2, Y: 2}) {
+		t.Errorf("Got %v %v", res, err)
+	}
+}
+
+func TestSharedRoomClose(t *testing.T) {
+	sr, err := NewSharedRoom(Room{X: 3, Y: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := sr.NewRobot("N", Coordinate{X: 0, Y: 0}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sr.Close(); !errors.Is(err, ErrRoomNotEmpty) {
+		t.Error("expected an error when closing a room with robots in it")
+	}
+
+	empty, err := NewSharedRoom(Room{X: 3, Y: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := empty.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := empty.NewRobot("N", Coordinate{X: 0, Y: 0}); !errors.Is(err, ErrRoomClosed) {
+		t.Error("expected an error when creating a robot in a closed room")
+	}
+}
+
+// Many robots try to move into the same cell at the same time. Exactly one of them should succeed.
+func TestSharedRoomRace(t *testing.T) {
+	sr, err := NewSharedRoom(Room{X: 3, Y: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target := Coordinate{X: 1, Y: 1}
+	starts := []struct {
+		d string
+		c Coordinate
+	}{{"S", Coordinate{X: 1, Y: 0}}, {"W", Coordinate{X: 2, Y: 1}}, {"N", Coordinate{X: 1, Y: 2}}, {"E", Coordinate{X: 0, Y: 1}}}
+
+	for i := 0; i < 100; i++ {
+		robots := []*Robot{}
+		for _, s := range starts {
+			r, err := sr.NewRobot(s.d, s.c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			robots = append(robots, r)
+		}
+
+		var wg sync.WaitGroup
+		results := make([]Result, len(robots))
+		for i, r := range robots {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i], _ = r.Cmd("F")
+			}()
+		}
+		wg.Wait()
+
+		arrived := 0
+		for _, res := range results {
+			if res.Coordinate == target {
+				arrived++
+			}
+		}
+		if arrived != 1 {
+			t.Fatalf("%d robots moved into the same cell", arrived)
+		}
+
+		// Start over with a fresh room.
+		sr, _ = NewSharedRoom(Room{X: 3, Y: 3})
+	}
+}
@@ -0,0 +1,46 @@
+package robot
+
+import (
+	"context"
+)
+
+/*
+Simulate predicts the result of executing the command string without moving the robot, see Cmd for how the string is executed.
+The commands are executed on a copy of the robot that is taken under the read lock, so the prediction is based on a consistent state and nothing is recorded in the history or the event log.
+In a shared room the other robots are treated as obstacles that stay where they are.
+*/
+func (r *Robot) Simulate(cs string, opts ...CmdOption) (Result, error) {
+	return r.SimulateContext(context.Background(), cs, opts...)
+}
+
+// SimulateContext predicts the result of executing the command string like Simulate, but aborts the prediction with an ErrCanceled when the context is done, see CmdContext.
+func (r *Robot) SimulateContext(ctx context.Context, cs string, opts ...CmdOption) (Result, error) {
+	r.l.RLock()
+	defer r.l.RUnlock()
+
+	prog, err := parse(cs)
+	if err != nil {
+		res := Result{}
+		res.Direction, res.Coordinate = r.report()
+		return res, err
+	}
+
+	return r.clone().run(ctx, prog, newCmdConfig(opts))
+}
+
+// Returns a copy of the robot in a room of its own that can be moved without affecting the robot or the shared room.
+// The cells occupied by other robots in a shared room are obstacles in the room of the copy.
+func (r *Robot) clone() *Robot {
+	c := &Robot{compass: r.compass, room: r.room, coordinate: r.coordinate, policy: r.policy}
+
+	if r.battery != nil {
+		b := *r.battery
+		c.battery = &b
+	}
+
+	if r.shared != nil {
+		c.room = r.room.withObstacles(r.shared.others(r))
+	}
+
+	return c
+}
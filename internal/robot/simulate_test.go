@@ -0,0 +1,94 @@
+package robot
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRobotSimulate(t *testing.T) {
+	var events []Event
+	r, err := NewRobot(Room{X: 3, Y: 3}, "N", Coordinate{X: 1, Y: 1}, WithCollisionPolicy(Stop), WithEventSink(func(e Event) {
+		events = append(events, e)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		cs     string
+		want_d string
+		want_c Coordinate
+		bumps  []int
+		err    error
+	}{
+		{"Move", "RF", "E", Coordinate{X: 2, Y: 1}, nil, nil},
+		{"Collision", "RFFL", "E", Coordinate{X: 2, Y: 1}, []int{2}, ErrCollision{Step: 2}},
+		{"Invalid command", "FX", "N", Coordinate{X: 1, Y: 1}, nil, ErrInvalidCommand{Index: 1, Rune: 'X'}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := r.Simulate(tt.cs)
+			if res.Direction != tt.want_d || res.Coordinate != tt.want_c || !reflect.DeepEqual(res.Collisions, tt.bumps) || !errors.Is(err, tt.err) {
+				t.Errorf("Got %+v %v, want %s %+v %v %v", res, err, tt.want_d, tt.want_c, tt.bumps, tt.err)
+			}
+		})
+	}
+
+	// The robot itself is never moved.
+	if d, c := r.Report(); d != "N" || c != (Coordinate{X: 1, Y: 1}) {
+		t.Errorf("Got %s %+v, want N {X:1 Y:1}", d, c)
+	}
+	if _, _, err := r.Undo(); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("Got %v, want %v", err, ErrNothingToUndo)
+	}
+	if len(events) != 1 {
+		t.Errorf("Got %d events, want 1", len(events))
+	}
+}
+
+func TestRobotSimulateContext(t *testing.T) {
+	r, err := NewRobot(Room{X: 5, Y: 5}, "E", Coordinate{X: 0, Y: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := r.SimulateContext(&countdownContext{Context: context.Background(), n: 2}, "4F")
+	if err != (ErrCanceled{Step: 2, Err: context.Canceled}) {
+		t.Errorf("Got %v, want to be canceled at step 2", err)
+	}
+	if res.Coordinate != (Coordinate{X: 2, Y: 0}) {
+		t.Errorf("Got %v, want {2 0}", res.Coordinate)
+	}
+
+	if d, c := r.Report(); d != "E" || c != (Coordinate{X: 0, Y: 0}) {
+		t.Errorf("Got %s %+v, want E {X:0 Y:0}", d, c)
+	}
+}
+
+func TestRobotSimulateSharedRoom(t *testing.T) {
+	sr, err := NewSharedRoom(Room{X: 3, Y: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := sr.NewRobot("E", Coordinate{X: 0, Y: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sr.NewRobot("N", Coordinate{X: 2, Y: 0}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The other robot is an obstacle, but the cells of the room are left untouched by the simulation.
+	res, err := a.Simulate("FF")
+	if err != nil || res.Coordinate != (Coordinate{X: 1, Y: 0}) || !reflect.DeepEqual(res.Collisions, []int{1}) {
+		t.Errorf("Got %+v %v", res, err)
+	}
+	if _, err := sr.NewRobot("N", Coordinate{X: 1, Y: 0}); err != nil {
+		t.Errorf("Got %v, want the cell to be free", err)
+	}
+}
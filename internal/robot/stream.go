@@ -0,0 +1,109 @@
+package robot
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strings"
+	"unicode"
+)
+
+/*
+CmdStream executes a command string that is read from rd as it arrives, see Cmd for how it is executed. White space between instructions is ignored, so the command string can be split into lines.
+Every instruction is executed as soon as it has been read, so only the group that is being read is kept in memory. This also means that the instructions before an invalid command or a syntax error have already been executed when the error is found.
+The robot is only locked while an instruction is executed, so Report returns the state of the robot in the middle of the stream. The progress callback, see WithProgress, is called after each instruction with the robot unlocked and only with the latest progress of the instruction, so a long instruction like "1000000F" is reported once. While the stream is read, Cmd, Goto, Undo, Redo and Submit return ErrBusy, and so does CmdStream.
+The execution is aborted with an ErrCanceled when the context is done and stops at the first error from rd, which is returned unless it is io.EOF.
+The whole stream is recorded as a single command batch in the history and the event log. The event log gets the executed instructions as they were read, e.g. "3[FR]", so repeats are not expanded.
+*/
+func (r *Robot) CmdStream(ctx context.Context, rd io.Reader, opts ...CmdOption) (Result, error) {
+	r.l.Lock()
+	res := Result{}
+	res.Direction, res.Coordinate = r.report()
+
+	if r.busy() {
+		r.l.Unlock()
+		return res, ErrBusy
+	}
+
+	r.streaming = true
+	r.record()
+	r.l.Unlock()
+
+	// The executed instructions are only collected if they are needed for the event log.
+	var cmds *strings.Builder
+	if r.sink != nil {
+		cmds = &strings.Builder{}
+	}
+
+	cfg := newCmdConfig(opts)
+	p := &parser{src: bufio.NewReader(rd)}
+	err := r.stream(ctx, p, cfg, &res, cmds)
+
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	r.streaming = false
+	if cmds != nil {
+		r.emit(EventCmd, cmds.String())
+	}
+
+	res.Direction, res.Coordinate = r.report()
+	return res, err
+}
+
+// Reads and executes instructions until the end of the stream or the first error. The executed instructions are written to cmds if it is not nil, see prefix.
+func (r *Robot) stream(ctx context.Context, p *parser, cfg cmdConfig, res *Result, cmds *strings.Builder) error {
+	// Reading fails when the stream is closed because the context is done, e.g. when the client of a request goes away.
+	fail := func(err error) error {
+		if ctx.Err() != nil {
+			return ErrCanceled{Step: res.Steps, Err: ctx.Err()}
+		}
+		return err
+	}
+
+	// Only the latest progress of an instruction is kept and it is reported once the robot has been unlocked, so a slow callback does not block the robot.
+	report := cfg.progress
+	var latest Progress
+	pending := false
+	if report != nil {
+		cfg.progress = func(pr Progress) {
+			latest, pending = pr, true
+		}
+	}
+
+	for {
+		c, err := p.read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fail(err)
+		}
+		if unicode.IsSpace(c) {
+			continue
+		}
+
+		p.unread()
+		ins, err := p.instruction()
+		if err != nil {
+			return fail(err)
+		}
+
+		r.l.Lock()
+		steps := res.Steps
+		err = r.steps(ctx, []instruction{ins}, cfg, res)
+		if cmds != nil {
+			cmds.WriteString(prefix([]instruction{ins}, res.Steps-steps))
+		}
+		r.l.Unlock()
+
+		if pending {
+			report(latest)
+			pending = false
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package robot
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestRobotCmdStream(t *testing.T) {
+	var events []Event
+	r, err := NewRobot(Room{X: 5, Y: 5}, "N", Coordinate{X: 0, Y: 4}, WithEventSink(func(e Event) {
+		events = append(events, e)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pr, pw := io.Pipe()
+	var progress []Progress
+	done := make(chan struct{})
+	var res Result
+
+	go func() {
+		defer close(done)
+		res, err = r.CmdStream(context.Background(), pr, WithProgress(2, func(p Progress) {
+			progress = append(progress, p)
+		}))
+	}()
+
+	pw.Write([]byte("FF"))
+
+	// The first instructions are executed before the rest of the stream has arrived.
+	deadline := time.Now().Add(5 * time.Second)
+	for _, c := r.Report(); c != (Coordinate{X: 0, Y: 2}); _, c = r.Report() {
+		if time.Now().After(deadline) {
+			t.Fatal("the first instructions were not executed in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := r.Cmd("F"); !errors.Is(err, ErrBusy) {
+		t.Errorf("Got %v, want %v", err, ErrBusy)
+	}
+
+	pw.Write([]byte("\nR 2[F"))
+	pw.Write([]byte("]\n"))
+	pw.Close()
+	<-done
+
+	if err != nil || res.Direction != "E" || res.Coordinate != (Coordinate{X: 2, Y: 2}) || res.Steps != 5 {
+		t.Errorf("Got %+v %v, want E {2 2} after 5 steps", res, err)
+	}
+	want := []Progress{{Steps: 2, Pose: Pose{"N", Coordinate{X: 0, Y: 2}}}, {Steps: 4, Pose: Pose{"E", Coordinate{X: 1, Y: 2}}}}
+	if !reflect.DeepEqual(progress, want) {
+		t.Errorf("Got progress %+v, want %+v", progress, want)
+	}
+
+	// The stream is a single command batch.
+	if len(events) != 2 || events[1].Cmd != "FFR2[F]" {
+		t.Errorf("Got events %+v", events)
+	}
+	if _, c, err := r.Undo(); err != nil || c != (Coordinate{X: 0, Y: 4}) {
+		t.Errorf("Got %v %v, want {0 4}", c, err)
+	}
+}
+
+func TestRobotCmdStreamErrors(t *testing.T) {
+	errRead := errors.New("read failed")
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		rd      io.Reader
+		want    Coordinate
+		wantErr error
+	}{
+		{"Invalid command", context.Background(), strings.NewReader("FF X"), Coordinate{X: 0, Y: 2}, ErrInvalidCommand{Index: 3, Rune: 'X'}},
+		{"Syntax error", context.Background(), strings.NewReader("F 2[F"), Coordinate{X: 0, Y: 3}, ErrSyntax{Index: 5, Msg: "missing ']'"}},
+		{"Read error", context.Background(), io.MultiReader(strings.NewReader("F"), iotest.ErrReader(errRead)), Coordinate{X: 0, Y: 3}, errRead},
+		{"Canceled", canceled, strings.NewReader("FF"), Coordinate{X: 0, Y: 4}, ErrCanceled{Step: 0, Err: context.Canceled}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewRobot(Room{X: 5, Y: 5}, "N", Coordinate{X: 0, Y: 4})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res, err := r.CmdStream(tt.ctx, tt.rd)
+			if err != tt.wantErr || res.Coordinate != tt.want {
+				t.Errorf("Got %v %v, want %v %v", res.Coordinate, err, tt.want, tt.wantErr)
+			}
+
+			// The robot can be commanded again after the stream.
+			if _, err := r.Cmd("R"); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestRobotCmdStreamProgressUnlocked(t *testing.T) {
+	r, err := NewRobot(Room{X: 5, Y: 5}, "E", Coordinate{X: 0, Y: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The callback can use the robot, since it is not called while the robot is locked.
+	var got []Coordinate
+	var steps []int
+	_, err = r.CmdStream(context.Background(), strings.NewReader("3F R"), WithProgress(1, func(p Progress) {
+		_, c := r.Report()
+		got = append(got, c)
+		steps = append(steps, p.Steps)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the latest progress of each instruction is reported.
+	want := []Coordinate{{X: 3, Y: 0}, {X: 3, Y: 0}}
+	if !reflect.DeepEqual(got, want) || !reflect.DeepEqual(steps, []int{3, 4}) {
+		t.Errorf("Got %v after %v steps, want %v after [3 4] steps", got, steps, want)
+	}
+}
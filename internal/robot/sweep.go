@@ -0,0 +1,145 @@
+package robot
+
+import (
+	"strings"
+)
+
+// SweepPlan is a command string that makes the robot enter every cell of its room that it can reach, see PlanSweep.
+type SweepPlan struct {
+	Cmds string
+	// The number of turns, i.e. L and R commands, in Cmds.
+	Turns int
+	// The number of free cells that the plan covers, including the cell the robot starts in, and the number of free cells the robot can not reach.
+	Covered     int
+	Unreachable int
+}
+
+/*
+PlanSweep generates a boustrophedon (lawn-mower) plan from the current state of the robot: the room is covered line by line and every other line is covered in the opposite direction.
+The lines are rows if the robot is facing east or west and columns if it is facing north or south, and the sweep starts at the end of the room that is closest to the robot.
+Cells that are skipped because of obstacles are covered by detours that are planned like Plan. In a shared room the other robots are treated as obstacles that stay where they are.
+*/
+func (r *Robot) PlanSweep() SweepPlan {
+	r.l.RLock()
+	defer r.l.RUnlock()
+
+	return r.clone().sweep()
+}
+
+// Generates the sweep plan. The robot must not be shared, see clone.
+func (r *Robot) sweep() SweepPlan {
+	room := r.room
+	reachable := r.reachable()
+	visited := make([]bool, room.cells())
+	index := func(c Coordinate) uint { return c.Y*room.X + c.X }
+
+	var sb strings.Builder
+	res := SweepPlan{Covered: 1}
+	c, dir := r.coordinate, r.compass.index
+	visited[index(c)] = true
+
+	p := newPlanner(r)
+	for _, target := range r.sweepOrder() {
+		if !room.free(target) {
+			continue
+		}
+		if !reachable[index(target)] {
+			res.Unreachable++
+			continue
+		}
+		if visited[index(target)] {
+			continue
+		}
+
+		// Most of the time the next cell is straight ahead, which does not need a search.
+		cmds := []byte{'F'}
+		if next, ok := room.step(c, dir, r.policy); !ok || next != target {
+			cmds, _, _ = p.route(c, dir, target, false)
+		}
+
+		for _, cmd := range cmds {
+			switch cmd {
+			case 'L':
+				dir = (dir + uint(len(directions)) - r.compass.mode.step()) % uint(len(directions))
+				res.Turns++
+			case 'R':
+				dir = (dir + r.compass.mode.step()) % uint(len(directions))
+				res.Turns++
+			case 'F':
+				c, _ = room.step(c, dir, r.policy)
+				if !visited[index(c)] {
+					visited[index(c)] = true
+					res.Covered++
+				}
+			}
+		}
+
+		sb.Write(cmds)
+	}
+
+	res.Cmds = sb.String()
+	return res
+}
+
+// Returns the cells of the room in the order they are swept.
+func (r *Robot) sweepOrder() []Coordinate {
+	// The sweep is described along lines and positions on the lines, which are rows or columns depending on the direction the robot is facing.
+	columns := r.compass.index%4 == 0
+	lines, length := r.room.Y, r.room.X
+	line, pos := r.coordinate.Y, r.coordinate.X
+	if columns {
+		lines, length = length, lines
+		line, pos = pos, line
+	}
+
+	order := make([]Coordinate, 0, lines*length)
+	reverse := pos*2 >= length
+
+	for i := uint(0); i < lines; i++ {
+		l := i
+		if line*2 >= lines {
+			l = lines - 1 - i
+		}
+
+		for j := uint(0); j < length; j++ {
+			p := j
+			if reverse {
+				p = length - 1 - j
+			}
+
+			if columns {
+				order = append(order, Coordinate{X: l, Y: p})
+			} else {
+				order = append(order, Coordinate{X: p, Y: l})
+			}
+		}
+
+		reverse = !reverse
+	}
+
+	return order
+}
+
+// Returns which cells the robot can reach from its current position, indexed by y*width + x.
+func (r *Robot) reachable() []bool {
+	seen := make([]bool, r.room.cells())
+	start := r.coordinate.Y*r.room.X + r.coordinate.X
+	seen[start] = true
+
+	// The queue holds cell indexes rather than coordinates, since it can hold most of the room.
+	queue := []uint32{uint32(start)}
+	for i := 0; i < len(queue); i++ {
+		c := Coordinate{X: uint(queue[i]) % r.room.X, Y: uint(queue[i]) / r.room.X}
+
+		for d := uint(0); d < uint(len(directions)); d += r.compass.mode.step() {
+			if next, ok := r.room.step(c, d, r.policy); ok {
+				if n := next.Y*r.room.X + next.X; !seen[n] {
+					seen[n] = true
+					queue = append(queue, uint32(n))
+				}
+			}
+		}
+	}
+
+	return seen
+}
@@ -0,0 +1,66 @@
+package robot
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRobotPlanSweep(t *testing.T) {
+	enclosed, err := NewGridMap([]string{
+		"..#.",
+		".#..",
+		"#...",
+		"....",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		room        Room
+		d           string
+		start       Coordinate
+		opts        []Option
+		want        string
+		turns       int
+		covered     int
+		unreachable int
+	}{
+		{Room{X: 3, Y: 3}, "E", Coordinate{X: 0, Y: 0}, nil, "FFRFRFFLFLFF", 4, 9, 0},
+		{Room{X: 3, Y: 1}, "E", Coordinate{X: 1, Y: 0}, nil, "LLFLLFF", 4, 3, 0},
+		{Room{X: 1, Y: 1}, "N", Coordinate{X: 0, Y: 0}, nil, "", 0, 1, 0},
+		// Facing north the room is swept column by column.
+		{Room{X: 3, Y: 3}, "N", Coordinate{X: 0, Y: 2}, nil, "FFRFRFFLFLFF", 4, 9, 0},
+		// Around an obstacle.
+		{Room{X: 3, Y: 3, Obstacles: []Coordinate{{X: 1, Y: 1}}}, "E", Coordinate{X: 0, Y: 0}, nil, "FFRFFRFFRF", 3, 8, 0},
+		// The three cells in the top left corner can not be reached, unless the robot can move diagonally.
+		{Room{Map: enclosed}, "E", Coordinate{X: 0, Y: 3}, nil, "", -1, 10, 3},
+		{Room{Map: enclosed}, "E", Coordinate{X: 0, Y: 3}, []Option{WithCompassMode(EightPoint)}, "", -1, 13, 0},
+	}
+
+	for _, tt := range tests {
+		tname := fmt.Sprintf("Test sweep: %v %s %v", tt.room, tt.d, tt.start)
+
+		t.Run(tname, func(t *testing.T) {
+			r, err := NewRobot(tt.room, tt.d, tt.start, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			plan := r.PlanSweep()
+			if (tt.want != "" && plan.Cmds != tt.want) || (tt.turns >= 0 && plan.Turns != tt.turns) || plan.Covered != tt.covered || plan.Unreachable != tt.unreachable {
+				t.Errorf("Got %+v, want %q turns %d covered %d unreachable %d", plan, tt.want, tt.turns, tt.covered, tt.unreachable)
+			}
+			if plan.Turns != strings.Count(plan.Cmds, "L")+strings.Count(plan.Cmds, "R") {
+				t.Errorf("Got %d turns in %q", plan.Turns, plan.Cmds)
+			}
+
+			// Executing the plan never collides.
+			res, err := r.Cmd(plan.Cmds)
+			if err != nil || res.Bumps() != 0 {
+				t.Errorf("Got %+v %v executing %q", res, err, plan.Cmds)
+			}
+		})
+	}
+}
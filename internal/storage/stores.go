@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"sync"
 
 	"github.com/anfly0/cuddly-octo-bassoon/internal/robot"
@@ -38,3 +39,128 @@ func (rs *RobotMemeStore) Put(id string, r *robot.Robot, _ context.Context) erro
 	rs.m[id] = r
 	return nil
 }
+
+var ErrRoomNotFound = errors.New("room not found")
+
+type RoomStore interface {
+	Get(id string, ctx context.Context) *robot.SharedRoom
+	Put(id string, r *robot.SharedRoom, ctx context.Context) error
+	// Deletes the room. Deleting a room that still has robots in it is rejected with an error.
+	Delete(id string, ctx context.Context) error
+	// Records that the robot with the given id has been created in the room.
+	Join(id string, robotId string, ctx context.Context) error
+	// Returns the ids of the robots in the room.
+	Robots(id string, ctx context.Context) []string
+}
+
+type roomEntry struct {
+	room   *robot.SharedRoom
+	robots []string
+}
+
+type RoomMemStore struct {
+	m map[string]*roomEntry
+	l sync.RWMutex
+}
+
+func NewRoomMemStore() *RoomMemStore {
+	m := make(map[string]*roomEntry)
+	return &RoomMemStore{m: m, l: sync.RWMutex{}}
+}
+
+// In this implementation we ignore the context. But in a room store where a cancellations makes sense it is useful.
+func (rs *RoomMemStore) Get(id string, _ context.Context) *robot.SharedRoom {
+	rs.l.RLock()
+	defer rs.l.RUnlock()
+
+	if e, ok := rs.m[id]; ok {
+		return e.room
+	}
+	return nil
+}
+
+// In this implementation we ignore the context. But in a room store where a cancellations makes sense it is useful.
+func (rs *RoomMemStore) Put(id string, r *robot.SharedRoom, _ context.Context) error {
+	rs.l.Lock()
+	defer rs.l.Unlock()
+
+	rs.m[id] = &roomEntry{room: r}
+	return nil
+}
+
+// The room is closed before it is removed from the store, so no robots can be created in it after it has been deleted.
+func (rs *RoomMemStore) Delete(id string, _ context.Context) error {
+	rs.l.Lock()
+	defer rs.l.Unlock()
+
+	e, ok := rs.m[id]
+	if !ok {
+		return ErrRoomNotFound
+	}
+
+	if err := e.room.Close(); err != nil {
+		return err
+	}
+
+	delete(rs.m, id)
+	return nil
+}
+
+func (rs *RoomMemStore) Join(id string, robotId string, _ context.Context) error {
+	rs.l.Lock()
+	defer rs.l.Unlock()
+
+	e, ok := rs.m[id]
+	if !ok {
+		return ErrRoomNotFound
+	}
+
+	e.robots = append(e.robots, robotId)
+	return nil
+}
+
+func (rs *RoomMemStore) Robots(id string, _ context.Context) []string {
+	rs.l.RLock()
+	defer rs.l.RUnlock()
+
+	e, ok := rs.m[id]
+	if !ok {
+		return nil
+	}
+
+	return append([]string(nil), e.robots...)
+}
+
+// EventLog stores the events of every robot, see robot.Event.
+type EventLog interface {
+	Append(id string, e robot.Event, ctx context.Context) error
+	// Returns the events of the robot with the given id in the order they were appended.
+	Events(id string, ctx context.Context) []robot.Event
+}
+
+type EventMemLog struct {
+	m map[string][]robot.Event
+	l sync.RWMutex
+}
+
+func NewEventMemLog() *EventMemLog {
+	m := make(map[string][]robot.Event)
+	return &EventMemLog{m: m, l: sync.RWMutex{}}
+}
+
+// In this implementation we ignore the context. But in an event log where a cancellations makes sense it is useful.
+func (el *EventMemLog) Append(id string, e robot.Event, _ context.Context) error {
+	el.l.Lock()
+	defer el.l.Unlock()
+
+	el.m[id] = append(el.m[id], e)
+	return nil
+}
+
+// In this implementation we ignore the context. But in an event log where a cancellations makes sense it is useful.
+func (el *EventMemLog) Events(id string, _ context.Context) []robot.Event {
+	el.l.RLock()
+	defer el.l.RUnlock()
+
+	return append([]robot.Event(nil), el.m[id]...)
+}